@@ -1,27 +1,78 @@
 package connections
 
 import (
-    "sync"
-    "github.com/gorilla/websocket"
+	"sync"
+
+	"github.com/gorilla/websocket"
 )
 
+// Client is a live WebSocket opened by one of a user's sessions.
+type Client struct {
+	UserID    string
+	SessionID string
+	Conn      *websocket.Conn
+	mu        sync.Mutex
+}
+
+// WriteJSON serializes writes, since a connection may be written to by
+// several goroutines at once.
+func (c *Client) WriteJSON(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteJSON(v)
+}
+
+func (c *Client) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// Clients maps a user ID to a *sync.Map of session ID to *Client, so a user
+// can be connected from several devices at once.
 var Clients sync.Map
 
-func GetClients() *sync.Map {
-    return &Clients
+func StoreClient(userID, sessionID string, conn *websocket.Conn) *Client {
+	client := &Client{UserID: userID, SessionID: sessionID, Conn: conn}
+	sessions, _ := Clients.LoadOrStore(userID, &sync.Map{})
+	if previous, loaded := sessions.(*sync.Map).Swap(sessionID, client); loaded {
+		// a session only keeps its newest connection
+		previous.(*Client).Conn.Close()
+	}
+	return client
 }
 
-func StoreClient(userID string, conn *websocket.Conn) {
-    Clients.Store(userID, conn)
+// DeleteClient removes the client if it is still the session's connection.
+func DeleteClient(client *Client) {
+	if sessions, ok := Clients.Load(client.UserID); ok {
+		sessions.(*sync.Map).CompareAndDelete(client.SessionID, client)
+	}
 }
 
-func DeleteClient(userID string) {
-    Clients.Delete(userID)
+// GetClients returns every live connection of the user.
+func GetClients(userID string) []*Client {
+	var clients []*Client
+	if sessions, ok := Clients.Load(userID); ok {
+		sessions.(*sync.Map).Range(func(_, value any) bool {
+			clients = append(clients, value.(*Client))
+			return true
+		})
+	}
+	return clients
 }
 
-func GetClient(userID string) (*websocket.Conn, bool) {
-    if conn, ok := Clients.Load(userID); ok {
-        return conn.(*websocket.Conn), true
-    }
-    return nil, false
-}
\ No newline at end of file
+// CloseSession terminates the live connection of a session, if any.
+func CloseSession(userID, sessionID string) {
+	if sessions, ok := Clients.Load(userID); ok {
+		if client, ok := sessions.(*sync.Map).LoadAndDelete(sessionID); ok {
+			client.(*Client).Conn.Close()
+		}
+	}
+}
+
+// CloseUser terminates every live connection of the user.
+func CloseUser(userID string) {
+	for _, client := range GetClients(userID) {
+		CloseSession(userID, client.SessionID)
+	}
+}
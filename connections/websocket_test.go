@@ -0,0 +1,110 @@
+package connections
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dial opens a WebSocket and returns the client end and the server's end.
+func dial(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	serverConns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		serverConns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, <-serverConns
+}
+
+// closed reports whether the server closed the client's connection.
+func closed(conn *websocket.Conn) bool {
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, _, err := conn.ReadMessage()
+	var netErr net.Error
+	return err != nil && !(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+func sessionIDs(userID string) map[string]bool {
+	ids := map[string]bool{}
+	for _, client := range GetClients(userID) {
+		ids[client.SessionID] = true
+	}
+	return ids
+}
+
+func TestCloseSessionOnlyClosesThatSession(t *testing.T) {
+	phone, phoneServer := dial(t)
+	laptop, laptopServer := dial(t)
+	StoreClient("user-1", "phone", phoneServer)
+	StoreClient("user-1", "laptop", laptopServer)
+	t.Cleanup(func() { CloseUser("user-1") })
+
+	CloseSession("user-1", "phone")
+
+	if !closed(phone) {
+		t.Error("phone connection still open")
+	}
+	if closed(laptop) {
+		t.Error("laptop connection closed")
+	}
+	if ids := sessionIDs("user-1"); len(ids) != 1 || !ids["laptop"] {
+		t.Errorf("live sessions = %v, want laptop", ids)
+	}
+}
+
+func TestStoreClientReplacesSessionConnection(t *testing.T) {
+	first, firstServer := dial(t)
+	second, secondServer := dial(t)
+	old := StoreClient("user-2", "phone", firstServer)
+	StoreClient("user-2", "phone", secondServer)
+	t.Cleanup(func() { CloseUser("user-2") })
+
+	if !closed(first) {
+		t.Error("replaced connection still open")
+	}
+	if closed(second) {
+		t.Error("new connection closed")
+	}
+
+	// the replaced connection's handler exiting leaves the new one registered
+	DeleteClient(old)
+	if clients := GetClients("user-2"); len(clients) != 1 || clients[0].Conn != secondServer {
+		t.Errorf("clients = %v, want the new connection", clients)
+	}
+}
+
+func TestCloseUserClosesEverySession(t *testing.T) {
+	phone, phoneServer := dial(t)
+	laptop, laptopServer := dial(t)
+	StoreClient("user-3", "phone", phoneServer)
+	StoreClient("user-3", "laptop", laptopServer)
+
+	CloseUser("user-3")
+
+	if !closed(phone) || !closed(laptop) {
+		t.Error("a connection is still open")
+	}
+	if clients := GetClients("user-3"); len(clients) != 0 {
+		t.Errorf("clients = %v, want none", clients)
+	}
+}
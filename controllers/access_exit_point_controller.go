@@ -20,7 +20,7 @@ func CreateAccessExitPoint(c *gin.Context) {
 		return
 	}
 
-	if err := services.CreateAccessExitPoint(database.DB, &point); err != nil {
+	if err := services.CreateAccessExitPoint(database.DB, auditActor(c), &point); err != nil {
 		log.Default().Println("Error creating access exit point:", err)
 		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to create access exit point", err.Error(), nil)
 		c.JSON(http.StatusBadRequest, rd)
@@ -59,7 +59,7 @@ func GetAccessExitPoint(c *gin.Context) {
 
 func DeleteAccessExitPoint(c *gin.Context) {
 	id := c.Param("id")
-	if err := services.DeleteAccessExitPoint(database.DB, id); err != nil {
+	if err := services.DeleteAccessExitPoint(database.DB, auditActor(c), id); err != nil {
 		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to delete access exit point", err.Error(), nil)
 		c.JSON(http.StatusBadRequest, rd)
 		return
@@ -84,7 +84,7 @@ func UpdateAccessExitPoint(c *gin.Context) {
 		return
 	}
 
-	if err := services.UpdateAccessExitPoint(database.DB, &point); err != nil {
+	if err := services.UpdateAccessExitPoint(database.DB, auditActor(c), &point); err != nil {
 		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to update access exit point", err.Error(), nil)
 		c.JSON(http.StatusBadRequest, rd)
 		return
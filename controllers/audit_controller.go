@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditActor identifies who is making the request for the audit log.
+func auditActor(c *gin.Context) models.AuditActor {
+	actor := models.AuditActor{
+		Type:      models.AuditActorSystem,
+		IPAddress: c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+	}
+
+	if userID := c.GetString("user_id"); userID != "" {
+		actor.Type = models.AuditActorUser
+		actor.ID = userID
+	} else if client, ok := c.Get("machine_client"); ok {
+		actor.Type = models.AuditActorMachine
+		actor.ID = client.(*models.MachineClient).ID
+	}
+
+	return actor
+}
+
+func GetAuditEvents(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	filters := models.AuditFilters{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid from date format. Use YYYY-MM-DD", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+		filters.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid to date format. Use YYYY-MM-DD", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+		// include the whole day
+		to = to.Add(24*time.Hour - time.Nanosecond)
+		filters.To = &to
+	}
+
+	response, code, err := services.GetAuditEvents(database.DB, filters, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching audit events:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch audit events", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Audit events retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func VerifyActivityChains(c *gin.Context) {
+	var from, to *time.Time
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid from date format. Use YYYY-MM-DD", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+		from = &parsed
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid to date format. Use YYYY-MM-DD", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+		// include the whole day
+		parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+		to = &parsed
+	}
+
+	reports, code, err := services.VerifyActivityChains(database.DB, c.Query("chain"), from, to)
+	if err != nil {
+		log.Default().Println("Error verifying activity chains:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to verify activity records", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Activity records verified", reports)
+	c.JSON(code, rd)
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"survielx-backend/database"
 	"survielx-backend/models"
 	"survielx-backend/services"
@@ -10,9 +13,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// vehicle types come from the admin-managed catalog rather than a fixed list
+	v.RegisterValidation("vehicle_type", func(fl validator.FieldLevel) bool {
+		return services.IsVehicleType(fl.Field().String())
+	})
+	return v
+}
 
 func Register(c *gin.Context) {
 	var input models.RegisterInput
@@ -30,18 +43,15 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	role := "user"
-	if input.Role != "" {
-		role = input.Role
-	}
+	// privileged roles are provisioned through invitations only
 	user := models.User{
 		Name:     input.Name,
 		Email:    input.Email,
 		Password: input.Password,
-		Role:     role,
+		Role:     "user",
 	}
 
-	createdUser, code, err := services.Register(database.DB, &user)
+	createdUser, code, err := services.Register(database.DB, auditActor(c), &user, clientInfo(c))
 	if err != nil {
 		log.Default().Println("Error registering user:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to register user", err.Error(), nil)
@@ -69,15 +79,265 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	user, code, err := services.Login(input.Email, input.Password)
+	user, code, err := services.Login(input.Email, input.Password, clientInfo(c))
 	if err != nil {
 		log.Default().Println("Error logging in:", err)
+		setRetryAfter(c, err)
 		rd := utility.BuildErrorResponse(code, "error", "Login failed", err.Error(), nil)
 		c.JSON(code, rd)
 		return
 	}
 
+	if user.TwoFactorRequired {
+		rd := utility.BuildSuccessResponse(http.StatusOK, "Two-factor authentication required", user)
+		c.JSON(http.StatusOK, rd)
+		return
+	}
+
 	log.Default().Println("User logged in successfully:", user.Email)
 	rd := utility.BuildSuccessResponse(http.StatusOK, "Login successful", user)
 	c.JSON(http.StatusOK, rd)
 }
+
+func RefreshToken(c *gin.Context) {
+	var input models.RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.RefreshTokens(database.DB, input.RefreshToken)
+	if err != nil {
+		log.Default().Println("Error refreshing token:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to refresh token", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(http.StatusOK, "Token refreshed successfully", user)
+	c.JSON(http.StatusOK, rd)
+}
+
+func Logout(c *gin.Context) {
+	var input models.LogoutInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Default().Println("Error binding JSON:", err)
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+	}
+
+	userID := c.MustGet("user_id").(string)
+	claims := c.MustGet("token_claims").(jwt.MapClaims)
+
+	code, err := services.Logout(database.DB, auditActor(c), userID, claims, input.RefreshToken)
+	if err != nil {
+		log.Default().Println("Error logging out:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Logout failed", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Logged out successfully", nil)
+	c.JSON(code, rd)
+}
+
+func LogoutAll(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+	claims := c.MustGet("token_claims").(jwt.MapClaims)
+
+	code, err := services.LogoutAll(database.DB, auditActor(c), userID, claims)
+	if err != nil {
+		log.Default().Println("Error logging out of all sessions:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Logout failed", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Logged out of all sessions successfully", nil)
+	c.JSON(code, rd)
+}
+
+func ChangePassword(c *gin.Context) {
+	var input models.ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	userID := c.MustGet("user_id").(string)
+	code, err := services.ChangePassword(database.DB, auditActor(c), userID, input)
+	if err != nil {
+		log.Default().Println("Error changing password:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to change password", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Password changed successfully, please log in again", nil)
+	c.JSON(code, rd)
+}
+
+func ForgotPassword(c *gin.Context) {
+	var input models.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.RequestPasswordReset(database.DB, input.Email)
+	if err != nil {
+		log.Default().Println("Error requesting password reset:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to request password reset", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "If the email is registered, a password reset link has been sent", nil)
+	c.JSON(code, rd)
+}
+
+func ResetPassword(c *gin.Context) {
+	var input models.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.ResetPassword(database.DB, auditActor(c), input)
+	if err != nil {
+		log.Default().Println("Error resetting password:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to reset password", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Password reset successfully", nil)
+	c.JSON(code, rd)
+}
+
+func VerifyEmail(c *gin.Context) {
+	var input models.VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.VerifyEmail(database.DB, auditActor(c), input.Token)
+	if err != nil {
+		log.Default().Println("Error verifying email:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to verify email", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Email verified successfully", nil)
+	c.JSON(code, rd)
+}
+
+func ResendVerificationEmail(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+
+	code, err := services.ResendVerificationEmail(database.DB, userID)
+	if err != nil {
+		log.Default().Println("Error resending verification email:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to resend verification email", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Verification email sent", nil)
+	c.JSON(code, rd)
+}
+
+func LoginTwoFactor(c *gin.Context) {
+	var input models.TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.CompleteTwoFactorLogin(database.DB, input, clientInfo(c))
+	if err != nil {
+		log.Default().Println("Error completing two-factor login:", err)
+		setRetryAfter(c, err)
+		rd := utility.BuildErrorResponse(code, "error", "Login failed", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	log.Default().Println("User logged in successfully:", user.Email)
+	rd := utility.BuildSuccessResponse(http.StatusOK, "Login successful", user)
+	c.JSON(http.StatusOK, rd)
+}
+
+// setRetryAfter tells a throttled client when it may try to log in again.
+func setRetryAfter(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	}
+}
+
+// GetJWKS publishes the public keys that verify access tokens so other
+// services can validate them without a shared secret.
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, services.GetJWKS())
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateInvitation(c *gin.Context) {
+	var input models.CreateInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	invitation, code, err := services.CreateInvitation(database.DB, auditActor(c), input)
+	if err != nil {
+		log.Default().Println("Error creating invitation:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to create invitation", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Invitation sent successfully", invitation)
+	c.JSON(code, rd)
+}
+
+func GetInvitations(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetInvitations(database.DB, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching invitations:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch invitations", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Invitations retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func RevokeInvitation(c *gin.Context) {
+	invitationID := c.Param("id")
+	if err := utility.ValidateUUID(invitationID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid invitation ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.RevokeInvitation(database.DB, auditActor(c), invitationID)
+	if err != nil {
+		log.Default().Println("Error revoking invitation:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to revoke invitation", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Invitation revoked successfully", nil)
+	c.JSON(code, rd)
+}
+
+func AcceptInvitation(c *gin.Context) {
+	var input models.AcceptInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.AcceptInvitation(database.DB, auditActor(c), input, clientInfo(c))
+	if err != nil {
+		log.Default().Println("Error accepting invitation:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to accept invitation", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Account created successfully", user)
+	c.JSON(code, rd)
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+func UnlockAccount(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utility.ValidateUUID(userID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid user ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.UnlockAccountInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Default().Println("Error binding JSON:", err)
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.UnlockAccount(database.DB, auditActor(c), userID, input.IPAddress)
+	if err != nil {
+		log.Default().Println("Error unlocking account:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to unlock account", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Account unlocked successfully", nil)
+	c.JSON(code, rd)
+}
+
+func GetAccountLockouts(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetAccountLockouts(database.DB, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching lockouts:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch lockouts", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Lockouts retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
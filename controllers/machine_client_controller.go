@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateMachineClient(c *gin.Context) {
+	var input models.CreateMachineClientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	client, code, err := services.CreateMachineClient(database.DB, auditActor(c), input)
+	if err != nil {
+		log.Default().Println("Error creating machine client:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to create machine client", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Machine client created, store the secret now as it will not be shown again", client)
+	c.JSON(code, rd)
+}
+
+func GetMachineClients(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetMachineClients(database.DB, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching machine clients:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch machine clients", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Machine clients retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func RotateMachineClientSecret(c *gin.Context) {
+	clientID := c.Param("id")
+	if err := utility.ValidateUUID(clientID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid machine client ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	client, code, err := services.RotateMachineClientSecret(database.DB, auditActor(c), clientID)
+	if err != nil {
+		log.Default().Println("Error rotating machine client secret:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to rotate secret", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Secret rotated, the previous secret remains valid for a grace period", client)
+	c.JSON(code, rd)
+}
+
+func RevokeMachineClient(c *gin.Context) {
+	clientID := c.Param("id")
+	if err := utility.ValidateUUID(clientID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid machine client ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.RevokeMachineClient(database.DB, auditActor(c), clientID)
+	if err != nil {
+		log.Default().Println("Error revoking machine client:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to revoke machine client", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Machine client revoked successfully", nil)
+	c.JSON(code, rd)
+}
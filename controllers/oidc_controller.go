@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetOIDCProviders(c *gin.Context) {
+	rd := utility.BuildSuccessResponse(http.StatusOK, "Identity providers retrieved successfully", services.GetOIDCProviders())
+	c.JSON(http.StatusOK, rd)
+}
+
+func StartOIDCLogin(c *gin.Context) {
+	authorization, code, err := services.StartOIDCLogin(database.DB, c.Param("provider"))
+	if err != nil {
+		log.Default().Println("Error starting OIDC login:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to start login", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Redirect the user to the authorization URL", authorization)
+	c.JSON(code, rd)
+}
+
+// OIDCCallback accepts the provider redirect as query parameters, or the code
+// and state posted as JSON by a frontend that received the redirect.
+func OIDCCallback(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "Login failed", c.DefaultQuery("error_description", errCode), nil)
+		c.JSON(http.StatusUnauthorized, rd)
+		return
+	}
+
+	var input models.OIDCCallbackInput
+	if err := c.ShouldBind(&input); err != nil {
+		log.Default().Println("Error binding OIDC callback:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.CompleteOIDCLogin(database.DB, c.Param("provider"), input, clientInfo(c))
+	if err != nil {
+		log.Default().Println("Error completing OIDC login:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Login failed", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	if user.TwoFactorRequired {
+		rd := utility.BuildSuccessResponse(http.StatusOK, "Two-factor authentication required", user)
+		c.JSON(http.StatusOK, rd)
+		return
+	}
+
+	log.Default().Println("User logged in through identity provider:", user.Email)
+	rd := utility.BuildSuccessResponse(code, "Login successful", user)
+	c.JSON(code, rd)
+}
@@ -30,7 +30,7 @@ func UpdateUserProfile(c *gin.Context) {
 	}
 
 	userID := c.MustGet("user_id").(string)
-	code, err := services.UpdateUserProfile(database.DB, userID, &input)
+	code, err := services.UpdateUserProfile(database.DB, auditActor(c), userID, &input)
 	if err != nil {
 		fmt.Println("Error updating user profile:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to update profile", err.Error(), nil)
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPermissions(c *gin.Context) {
+	permissions, code, err := services.GetPermissions(database.DB)
+	if err != nil {
+		log.Default().Println("Error fetching permissions:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch permissions", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Permissions retrieved successfully", permissions)
+	c.JSON(code, rd)
+}
+
+func GetRolePermissions(c *gin.Context) {
+	roles, code, err := services.GetRolePermissions(database.DB)
+	if err != nil {
+		log.Default().Println("Error fetching role permissions:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch role permissions", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Role permissions retrieved successfully", roles)
+	c.JSON(code, rd)
+}
+
+func UpdateRolePermissions(c *gin.Context) {
+	role := c.Param("role")
+
+	var input models.UpdateRolePermissionsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	updated, code, err := services.SetRolePermissions(database.DB, auditActor(c), role, input.Permissions)
+	if err != nil {
+		log.Default().Println("Error updating role permissions:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to update role permissions", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Role permissions updated successfully", updated)
+	c.JSON(code, rd)
+}
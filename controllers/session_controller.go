@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clientInfo describes the device making the request. Apps can name the
+// device through the X-Device-Label header.
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		IPAddress:   c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		DeviceLabel: c.GetHeader("X-Device-Label"),
+	}
+}
+
+func GetUserSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+	claims := c.MustGet("token_claims").(jwt.MapClaims)
+	currentSessionID, _ := claims["sid"].(string)
+
+	sessions, code, err := services.GetUserSessions(database.DB, userID, currentSessionID)
+	if err != nil {
+		log.Default().Println("Error fetching sessions:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch sessions", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Sessions retrieved successfully", sessions)
+	c.JSON(code, rd)
+}
+
+func RevokeSession(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	if err := utility.ValidateUUID(sessionID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid session ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	userID := c.MustGet("user_id").(string)
+	code, err := services.RevokeSession(database.DB, auditActor(c), userID, sessionID)
+	if err != nil {
+		log.Default().Println("Error revoking session:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to revoke session", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Session revoked successfully", nil)
+	c.JSON(code, rd)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTwoFactor(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+
+	setup, code, err := services.SetupTwoFactor(database.DB, userID)
+	if err != nil {
+		log.Default().Println("Error setting up two-factor authentication:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to set up two-factor authentication", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Scan the provisioning URI with your authenticator app, then confirm with a code", setup)
+	c.JSON(code, rd)
+}
+
+func EnableTwoFactor(c *gin.Context) {
+	var input models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	userID := c.MustGet("user_id").(string)
+	codes, code, err := services.EnableTwoFactor(database.DB, auditActor(c), userID, input.Code)
+	if err != nil {
+		log.Default().Println("Error enabling two-factor authentication:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to enable two-factor authentication", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Two-factor authentication enabled, store your recovery codes safely", codes)
+	c.JSON(code, rd)
+}
+
+func DisableTwoFactor(c *gin.Context) {
+	var input models.DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	userID := c.MustGet("user_id").(string)
+	code, err := services.DisableTwoFactor(database.DB, auditActor(c), userID, input)
+	if err != nil {
+		log.Default().Println("Error disabling two-factor authentication:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to disable two-factor authentication", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Two-factor authentication disabled", nil)
+	c.JSON(code, rd)
+}
+
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var input models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	userID := c.MustGet("user_id").(string)
+	codes, code, err := services.RegenerateRecoveryCodes(database.DB, auditActor(c), userID, input.Code)
+	if err != nil {
+		log.Default().Println("Error regenerating recovery codes:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to regenerate recovery codes", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Recovery codes regenerated", codes)
+	c.JSON(code, rd)
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"survielx-backend/database"
+	"survielx-backend/models"
 	"survielx-backend/services"
 	"survielx-backend/utility"
 
@@ -11,16 +12,155 @@ import (
 )
 
 func GetUsers(c *gin.Context) {
+	pagination := models.GetPagination(c)
 
-	users, err := services.GetUsers(database.DB)
+	filters := models.UserFilters{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+		Email:  c.Query("email"),
+		Name:   c.Query("name"),
+		Status: c.Query("status"),
+	}
+
+	response, code, err := services.GetUsers(database.DB, pagination, filters)
 	if err != nil {
 		log.Default().Println("Error fetching users:", err)
-		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to get users", err.Error(), nil)
-		c.JSON(http.StatusBadRequest, rd)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to get users", err.Error(), nil)
+		c.JSON(code, rd)
 		return
 	}
 
 	log.Default().Println("Successfully fetched users")
-	rd := utility.BuildSuccessResponse(http.StatusOK, "Successfully fetched users", users)
-	c.JSON(http.StatusOK, rd)
+	rd := utility.BuildSuccessResponse(code, "Successfully fetched users", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func GetUserDetails(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utility.ValidateUUID(userID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid user ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	details, code, err := services.GetUserDetails(database.DB, userID)
+	if err != nil {
+		log.Default().Println("Error fetching user:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to get user", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "User retrieved successfully", details)
+	c.JSON(code, rd)
+}
+
+func UpdateUserRole(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utility.ValidateUUID(userID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid user ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.UpdateUserRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.ChangeUserRole(database.DB, auditActor(c), userID, input.Role)
+	if err != nil {
+		log.Default().Println("Error changing user role:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to change role", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "User role updated successfully", user)
+	c.JSON(code, rd)
+}
+
+func SuspendUser(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utility.ValidateUUID(userID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid user ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.SuspendUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.SuspendUser(database.DB, auditActor(c), userID, input.Reason)
+	if err != nil {
+		log.Default().Println("Error suspending user:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to suspend user", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "User suspended successfully", user)
+	c.JSON(code, rd)
+}
+
+func ReactivateUser(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utility.ValidateUUID(userID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid user ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	user, code, err := services.ReactivateUser(database.DB, auditActor(c), userID)
+	if err != nil {
+		log.Default().Println("Error reactivating user:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to reactivate user", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "User reactivated successfully", user)
+	c.JSON(code, rd)
+}
+
+func ResetUserPassword(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utility.ValidateUUID(userID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid user ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.AdminResetPassword(database.DB, auditActor(c), userID)
+	if err != nil {
+		log.Default().Println("Error resetting user password:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to reset password", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "User signed out and password reset link sent", nil)
+	c.JSON(code, rd)
 }
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"survielx-backend/database"
+	"survielx-backend/services"
+	"survielx-backend/storage"
+	"survielx-backend/utility"
+)
+
+// UploadVehicleAttachment accepts a multipart form with a "kind" field and
+// the file in "file".
+func UploadVehicleAttachment(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	maxBytes := services.AttachmentMaxBytes()
+	// leave room for the other form fields and multipart boundaries
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		log.Default().Println("Error reading upload:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid upload", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+	if header.Size > maxBytes {
+		rd := utility.BuildErrorResponse(http.StatusRequestEntityTooLarge, "error", "Invalid upload", "file is too large", nil)
+		c.JSON(http.StatusRequestEntityTooLarge, rd)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid upload", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid upload", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	attachment, code, err := services.UploadVehicleAttachment(database.DB, auditActor(c), vehicleID, c.PostForm("kind"), header.Filename, data)
+	if err != nil {
+		log.Default().Println("Error uploading vehicle attachment:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to upload attachment", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Attachment uploaded successfully", attachment)
+	c.JSON(code, rd)
+}
+
+// GetVehicleAttachments lists the attachments of a vehicle the caller drives.
+func GetVehicleAttachments(c *gin.Context) {
+	respondWithAttachments(c, c.MustGet("user_id").(string))
+}
+
+// GetAnyVehicleAttachments lists the attachments of any vehicle, for guards
+// checking a car at the gate.
+func GetAnyVehicleAttachments(c *gin.Context) {
+	respondWithAttachments(c, "")
+}
+
+func respondWithAttachments(c *gin.Context, userID string) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	attachments, code, err := services.GetVehicleAttachments(database.DB, vehicleID, userID)
+	if err != nil {
+		log.Default().Println("Error fetching vehicle attachments:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch attachments", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Attachments retrieved successfully", attachments)
+	c.JSON(code, rd)
+}
+
+func DeleteVehicleAttachment(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	attachmentID := c.Param("attachment_id")
+	for _, id := range []string{vehicleID, attachmentID} {
+		if err := utility.ValidateUUID(id); err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid ID", err.Error(), nil)
+			c.JSON(http.StatusBadRequest, rd)
+			return
+		}
+	}
+
+	code, err := services.DeleteVehicleAttachment(database.DB, auditActor(c), vehicleID, attachmentID)
+	if err != nil {
+		log.Default().Println("Error deleting vehicle attachment:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to delete attachment", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Attachment deleted successfully", nil)
+	c.JSON(code, rd)
+}
+
+// ServeBlob streams a locally stored blob to the holder of a signed link.
+// Other storage drivers sign links to the storage service directly.
+func ServeBlob(c *gin.Context) {
+	store, ok := storage.Default.(*storage.LocalStore)
+	if !ok {
+		rd := utility.BuildErrorResponse(http.StatusNotFound, "error", "file not found", "blobs are not served by this store", nil)
+		c.JSON(http.StatusNotFound, rd)
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := store.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "invalid file link", err.Error(), nil)
+		c.JSON(http.StatusForbidden, rd)
+		return
+	}
+
+	blob, contentType, err := store.Get(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		rd := utility.BuildErrorResponse(http.StatusNotFound, "error", "file not found", err.Error(), nil)
+		c.JSON(http.StatusNotFound, rd)
+		return
+	}
+	if err != nil {
+		log.Default().Println("Error reading blob:", err)
+		rd := utility.BuildErrorResponse(http.StatusInternalServerError, "error", "failed to read file", err.Error(), nil)
+		c.JSON(http.StatusInternalServerError, rd)
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, blob, nil)
+}
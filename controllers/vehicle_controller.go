@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -39,7 +41,7 @@ func RegisterVehicle(c *gin.Context) {
 		Type:        input.Type,
 	}
 
-	createdVehicle, code, err := services.RegisterVehicle(&vehicle)
+	createdVehicle, code, err := services.RegisterVehicle(auditActor(c), &vehicle)
 	if err != nil {
 		log.Default().Println("Error registering vehicle:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to register vehicle", err.Error(), nil)
@@ -47,7 +49,7 @@ func RegisterVehicle(c *gin.Context) {
 		return
 	}
 
-	rd := utility.BuildSuccessResponse(code, "Vehicle successfully registered", createdVehicle)
+	rd := utility.BuildSuccessResponse(code, "Vehicle submitted for review", createdVehicle)
 	c.JSON(code, rd)
 }
 
@@ -75,7 +77,7 @@ func UpdateVehicle(c *gin.Context) {
 		return
 	}
 
-	updatedVehicle, code, err := services.UpdateVehicle(database.DB, vehicle_id, input)
+	updatedVehicle, code, err := services.UpdateVehicle(database.DB, auditActor(c), vehicle_id, input)
 	if err != nil {
 		log.Default().Println("Error updating user vehicles:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to update user vehicles", err.Error(), nil)
@@ -88,10 +90,27 @@ func UpdateVehicle(c *gin.Context) {
 	c.JSON(code, rd)
 }
 
+// DeRegisterVehicle archives the vehicle. The JSON body with a reason is
+// optional.
 func DeRegisterVehicle(c *gin.Context) {
 	vehicle_id := c.Param("vehicle_id")
 
-	code, err := services.DeRegisterVehicle(database.DB, vehicle_id)
+	var input models.DeRegisterVehicleInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	code, err := services.DeRegisterVehicle(database.DB, auditActor(c), vehicle_id, input)
 	if err != nil {
 		log.Default().Println("Error deregistering vehicle:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to deregister vehicle", err.Error(), nil)
@@ -136,7 +155,7 @@ func LogVehicleActivity(c *gin.Context) {
 	}
 
 	input.VisitorType = models.VisitorTypeRegistered
-	code, err := services.LogVehicleActivity(database.DB, input)
+	code, err := services.LogVehicleActivity(database.DB, auditActor(c), input)
 	if err != nil {
 		log.Default().Println("Error logging vehicle activity:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to log vehicle activity", err.Error(), nil)
@@ -165,7 +184,18 @@ func SystemLogVehicleActivity(c *gin.Context) {
 		return
 	}
 
-	code, err := services.LogVehicleActivity(database.DB, input)
+	client := c.MustGet("machine_client").(*models.MachineClient)
+	gateID := input.ExitPointID
+	if input.IsEntry {
+		gateID = input.EntryPointID
+	}
+	if !client.AllowsGate(gateID) {
+		rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Forbidden", "Machine client is not allowed to log activity for this gate", nil)
+		c.JSON(http.StatusForbidden, rd)
+		return
+	}
+
+	code, err := services.LogVehicleActivity(database.DB, auditActor(c), input)
 	if err != nil {
 		log.Default().Println("Error logging vehicle activity:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to log vehicle activity", err.Error(), nil)
@@ -294,7 +324,7 @@ func UpdatePendingVehicle(c *gin.Context) {
 	req.UserID = userID
 	req.ID = pending_id
 
-	code, err := services.UpdatePendingVehicle(database.DB, req)
+	code, err := services.UpdatePendingVehicle(database.DB, auditActor(c), req)
 	if err != nil {
 		log.Default().Println("Error fetching vehicles activities:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to get pending vehicles", err.Error(), nil)
@@ -334,8 +364,10 @@ func GetGuestVehicleActivitiesByPlateNumber(c *gin.Context) {
 
 func IdentifyVehicle(c *gin.Context) {
 	plateNumber := c.Param("plateNumber")
+	// match=fuzzy tolerates OCR misreads and ranks the vehicles it could be
+	fuzzy := c.DefaultQuery("match", services.PlateMatchExact) == services.PlateMatchFuzzy
 
-	resp, code, err := services.IdentifyVehicle(plateNumber)
+	data, code, err := services.IdentifyVehicle(plateNumber, fuzzy)
 	if err != nil {
 		log.Default().Println("Error getting vehicle status:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to get vehicle status", err.Error(), nil)
@@ -343,11 +375,7 @@ func IdentifyVehicle(c *gin.Context) {
 		return
 	}
 
-	data := models.VehicleIdentity{
-		PlateNumber:  plateNumber,
-		Status:       resp.Status,
-		IsRegistered: resp.IsRegistered,
-	}
+	data.PlateNumber = plateNumber
 
 	log.Default().Println("Vehicle status retrieved successfully for plate number:", plateNumber)
 	rd := utility.BuildSuccessResponse(http.StatusOK, "Vehicle status retrieved successfully", data)
@@ -406,7 +434,7 @@ func LogGuestVehicleActivity(c *gin.Context) {
 
 	input.VisitorType = models.VisitorTypeGuest
 
-	code, err := services.LogGuestVehicleActivity(database.DB, input)
+	code, err := services.LogGuestVehicleActivity(database.DB, auditActor(c), input)
 	if err != nil {
 		log.Default().Println("Error logging guest vehicle activity:", err)
 		rd := utility.BuildErrorResponse(code, "error", "Failed to log guest vehicle activity", err.Error(), nil)
@@ -555,3 +583,118 @@ func GenerateActivityReport(c *gin.Context) {
 	rd := utility.BuildSuccessResponse(statusCode, "Successfully generated activity report", response.Data, response.Pagination)
 	c.JSON(statusCode, rd)
 }
+
+func GetPlateConflicts(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetPlateConflicts(database.DB, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching plate conflicts:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch plate conflicts", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Plate conflicts retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func GetVehicleReviewQueue(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetVehicleReviewQueue(database.DB, c.Query("status"), pagination)
+	if err != nil {
+		log.Default().Println("Error fetching vehicle review queue:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch vehicle review queue", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle review queue retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func ReviewVehicle(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.ReviewVehicleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	vehicle, code, err := services.ReviewVehicle(database.DB, auditActor(c), vehicleID, input)
+	if err != nil {
+		log.Default().Println("Error reviewing vehicle:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to review vehicle", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle reviewed successfully", vehicle)
+	c.JSON(code, rd)
+}
+
+func GetArchivedVehicles(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetArchivedVehicles(database.DB, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching archived vehicles:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch archived vehicles", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Archived vehicles retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func RestoreVehicle(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.RestoreVehicleInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	vehicle, code, err := services.RestoreVehicle(database.DB, auditActor(c), vehicleID, input)
+	if err != nil {
+		log.Default().Println("Error restoring vehicle:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to restore vehicle", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle restored successfully", vehicle)
+	c.JSON(code, rd)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func InviteVehicleDriver(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.InviteVehicleDriverInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	driver, code, err := services.InviteVehicleDriver(database.DB, auditActor(c), vehicleID, input)
+	if err != nil {
+		log.Default().Println("Error inviting vehicle driver:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to invite driver", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Driver invited successfully", driver)
+	c.JSON(code, rd)
+}
+
+func GetVehicleDrivers(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	userID := c.MustGet("user_id").(string)
+	drivers, code, err := services.GetVehicleDrivers(database.DB, vehicleID, userID)
+	if err != nil {
+		log.Default().Println("Error fetching vehicle drivers:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch drivers", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Drivers retrieved successfully", drivers)
+	c.JSON(code, rd)
+}
+
+func GetSharedVehicles(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+
+	drivers, code, err := services.GetSharedVehicles(database.DB, userID)
+	if err != nil {
+		log.Default().Println("Error fetching shared vehicles:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch shared vehicles", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Shared vehicles retrieved successfully", drivers)
+	c.JSON(code, rd)
+}
+
+func AcceptVehicleDriver(c *gin.Context) {
+	respondToVehicleDriver(c, services.AcceptVehicleDriver, "accept", "Driver invitation accepted")
+}
+
+func DeclineVehicleDriver(c *gin.Context) {
+	respondToVehicleDriver(c, services.DeclineVehicleDriver, "decline", "Driver invitation declined")
+}
+
+func RevokeVehicleDriver(c *gin.Context) {
+	respondToVehicleDriver(c, services.RevokeVehicleDriver, "remove", "Driver removed")
+}
+
+func respondToVehicleDriver(c *gin.Context, action func(*gorm.DB, models.AuditActor, string) (*models.VehicleDriver, int, error), verb, message string) {
+	driverID := c.Param("driver_id")
+	if err := utility.ValidateUUID(driverID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid driver ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	driver, code, err := action(database.DB, auditActor(c), driverID)
+	if err != nil {
+		log.Default().Printf("Error trying to %s vehicle driver: %v", verb, err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to "+verb+" driver", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, message, driver)
+	c.JSON(code, rd)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportVehicles takes a CSV upload in "file". The "mode" query parameter is
+// dry_run, the default, or commit.
+func ImportVehicles(c *gin.Context) {
+	maxBytes := services.VehicleImportMaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		log.Default().Println("Error reading upload:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid upload", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+	if header.Size > maxBytes {
+		rd := utility.BuildErrorResponse(http.StatusRequestEntityTooLarge, "error", "Invalid upload", "file is too large", nil)
+		c.JSON(http.StatusRequestEntityTooLarge, rd)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid upload", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+	defer file.Close()
+
+	result, code, err := services.ImportVehicles(database.DB, auditActor(c), c.Query("mode"), file)
+	if err != nil {
+		log.Default().Println("Error importing vehicles:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to import vehicles", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	message := "Vehicles imported successfully"
+	if result.Mode == models.VehicleImportDryRun {
+		message = "Vehicle import checked successfully"
+	}
+	rd := utility.BuildSuccessResponse(code, message, result)
+	c.JSON(code, rd)
+}
+
+// ExportVehicles downloads vehicles as CSV in the layout ImportVehicles
+// accepts. The "status" query parameter picks the review status, approved by
+// default.
+func ExportVehicles(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.VehicleStatusPendingReview, models.VehicleStatusApproved, models.VehicleStatusRejected:
+	default:
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid status", fmt.Sprintf("unknown status %s", status), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	fileName := fmt.Sprintf("vehicles-%s.csv", time.Now().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Status(http.StatusOK)
+
+	// the response has started, so a failure can only cut the file short
+	if err := services.ExportVehicles(database.DB, c.Writer, status); err != nil {
+		log.Default().Println("Error exporting vehicles:", err)
+	}
+}
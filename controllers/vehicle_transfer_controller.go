@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func InitiateVehicleTransfer(c *gin.Context) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.CreateVehicleTransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	transfer, code, err := services.InitiateVehicleTransfer(database.DB, auditActor(c), vehicleID, input)
+	if err != nil {
+		log.Default().Println("Error initiating vehicle transfer:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to start vehicle transfer", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle transfer started", transfer)
+	c.JSON(code, rd)
+}
+
+func GetUserVehicleTransfers(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetUserVehicleTransfers(database.DB, userID, pagination)
+	if err != nil {
+		log.Default().Println("Error fetching vehicle transfers:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch vehicle transfers", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle transfers retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func GetVehicleTransfers(c *gin.Context) {
+	pagination := models.GetPagination(c)
+
+	response, code, err := services.GetVehicleTransfers(database.DB, c.Query("status"), pagination)
+	if err != nil {
+		log.Default().Println("Error fetching vehicle transfers:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch vehicle transfers", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle transfers retrieved successfully", response.Data, response.Pagination)
+	c.JSON(code, rd)
+}
+
+func AcceptVehicleTransfer(c *gin.Context) {
+	respondToVehicleTransfer(c, services.AcceptVehicleTransfer, "accept", "Vehicle transfer accepted")
+}
+
+func DeclineVehicleTransfer(c *gin.Context) {
+	respondToVehicleTransfer(c, services.DeclineVehicleTransfer, "decline", "Vehicle transfer declined")
+}
+
+func CancelVehicleTransfer(c *gin.Context) {
+	respondToVehicleTransfer(c, services.CancelVehicleTransfer, "cancel", "Vehicle transfer cancelled")
+}
+
+func respondToVehicleTransfer(c *gin.Context, action func(*gorm.DB, models.AuditActor, string) (*models.VehicleTransfer, int, error), verb, message string) {
+	transferID := c.Param("transfer_id")
+	if err := utility.ValidateUUID(transferID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid transfer ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	transfer, code, err := action(database.DB, auditActor(c), transferID)
+	if err != nil {
+		log.Default().Printf("Error trying to %s vehicle transfer: %v", verb, err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to "+verb+" vehicle transfer", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, message, transfer)
+	c.JSON(code, rd)
+}
+
+func ReviewVehicleTransfer(c *gin.Context) {
+	transferID := c.Param("transfer_id")
+	if err := utility.ValidateUUID(transferID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid transfer ID", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	var input models.ReviewVehicleTransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	transfer, code, err := services.ReviewVehicleTransfer(database.DB, auditActor(c), transferID, input)
+	if err != nil {
+		log.Default().Println("Error reviewing vehicle transfer:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to review vehicle transfer", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle transfer reviewed", transfer)
+	c.JSON(code, rd)
+}
+
+// GetVehicleOwnershipHistory lists the owners of one of the caller's vehicles.
+func GetVehicleOwnershipHistory(c *gin.Context) {
+	respondWithOwnershipHistory(c, c.MustGet("user_id").(string))
+}
+
+// GetAnyVehicleOwnershipHistory lists the owners of any vehicle, for security.
+func GetAnyVehicleOwnershipHistory(c *gin.Context) {
+	respondWithOwnershipHistory(c, "")
+}
+
+func respondWithOwnershipHistory(c *gin.Context, ownerID string) {
+	vehicleID := c.Param("vehicle_id")
+	if err := utility.ValidateUUID(vehicleID); err != nil {
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "invalid vehicle id", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	history, code, err := services.GetVehicleOwnershipHistory(database.DB, vehicleID, ownerID)
+	if err != nil {
+		log.Default().Println("Error fetching ownership history:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch ownership history", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Ownership history retrieved successfully", history)
+	c.JSON(code, rd)
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/models"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVehicleTypes lists the active vehicle types users can register.
+func GetVehicleTypes(c *gin.Context) {
+	respondWithVehicleTypes(c, false)
+}
+
+// GetAllVehicleTypes lists the whole catalog, including deactivated types.
+func GetAllVehicleTypes(c *gin.Context) {
+	respondWithVehicleTypes(c, true)
+}
+
+func respondWithVehicleTypes(c *gin.Context, includeInactive bool) {
+	vehicleTypes, code, err := services.GetVehicleTypes(database.DB, includeInactive)
+	if err != nil {
+		log.Default().Println("Error fetching vehicle types:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to fetch vehicle types", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle types retrieved successfully", vehicleTypes)
+	c.JSON(code, rd)
+}
+
+func CreateVehicleType(c *gin.Context) {
+	var input models.CreateVehicleTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	vehicleType, code, err := services.CreateVehicleType(database.DB, auditActor(c), input)
+	if err != nil {
+		log.Default().Println("Error creating vehicle type:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to create vehicle type", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle type created successfully", vehicleType)
+	c.JSON(code, rd)
+}
+
+func UpdateVehicleType(c *gin.Context) {
+	var input models.UpdateVehicleTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Default().Println("Error binding JSON:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Invalid input", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	if err := validate.Struct(input); err != nil {
+		log.Default().Println("Validation error:", err)
+		rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Validation failed", err.Error(), nil)
+		c.JSON(http.StatusBadRequest, rd)
+		return
+	}
+
+	vehicleType, code, err := services.UpdateVehicleType(database.DB, auditActor(c), c.Param("code"), input)
+	if err != nil {
+		log.Default().Println("Error updating vehicle type:", err)
+		rd := utility.BuildErrorResponse(code, "error", "Failed to update vehicle type", err.Error(), nil)
+		c.JSON(code, rd)
+		return
+	}
+
+	rd := utility.BuildSuccessResponse(code, "Vehicle type updated successfully", vehicleType)
+	c.JSON(code, rd)
+}
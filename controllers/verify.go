@@ -29,6 +29,11 @@ func VerifyVehicle(c *gin.Context) {
 		c.JSON(code, rd)
 		return
 	}
+	if !vehicle.IsApproved() {
+		rd := utility.BuildErrorResponse(http.StatusNotFound, "error", "Vehicle has not been registered", "vehicle registration has not been approved", nil)
+		c.JSON(http.StatusNotFound, rd)
+		return
+	}
 	
 	log.Default().Println("Vehicle verification successful for plate number:", input.PlateNumber)
 	rd := utility.BuildSuccessResponse(http.StatusOK, "Vehicle is registered", vehicle)
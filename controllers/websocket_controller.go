@@ -3,12 +3,11 @@ package controllers
 import (
 	"log"
 	"net/http"
-	"os"
 	"survielx-backend/connections"
+	"survielx-backend/database"
 	"survielx-backend/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
 )
 
@@ -27,19 +26,8 @@ func WSHandler(c *gin.Context) {
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	claims, err := services.AuthenticateAccessToken(database.DB, token)
 	if err != nil {
-		log.Println("WS upgrade error:", err)
-		return
-	}
-	defer conn.Close()
-
-	claims := jwt.MapClaims{}
-	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil || !parsedToken.Valid {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
 	}
@@ -50,18 +38,42 @@ func WSHandler(c *gin.Context) {
 		return
 	}
 
-	connections.StoreClient(userID, conn)
-	defer connections.DeleteClient(userID)
+	// the connection is tracked, listed and revoked as part of its session
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Println("WS upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	client := connections.StoreClient(userID, sessionID, conn)
+	defer connections.DeleteClient(client)
+
+	connectedAt, err := services.ConnectSession(database.DB, sessionID)
+	if err != nil {
+		log.Println("Failed to record WS connection:", err)
+	}
+	defer func() {
+		if err := services.DisconnectSession(database.DB, sessionID, connectedAt); err != nil {
+			log.Println("Failed to record WS disconnection:", err)
+		}
+	}()
 
 	for {
 
 		_, message, err := conn.ReadMessage()
 		if err != nil {
 			log.Println("WS read error:", err)
-			connections.DeleteClient(userID)
 			break
 		}
 
+		services.TouchSession(database.DB, sessionID)
 		services.HandleUserResponse(userID, message)
 	}
 
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"survielx-backend/models"
+	"survielx-backend/utility"
+
+	"gorm.io/gorm"
+)
+
+// encryptMachineClientKeys moves machine clients created before their keys
+// were encrypted off the plaintext secret_hash columns. The stored hash is
+// the key those clients sign with, so it is sealed as is and the old columns
+// are dropped.
+func encryptMachineClientKeys() error {
+	if !DB.Migrator().HasColumn(&models.MachineClient{}, "secret_hash") {
+		return nil
+	}
+
+	type row struct {
+		ID                 string
+		SecretHash         string
+		PreviousSecretHash string
+	}
+	var rows []row
+	err := DB.Table("machine_clients").
+		Select("id, COALESCE(secret_hash, '') AS secret_hash, COALESCE(previous_secret_hash, '') AS previous_secret_hash").
+		Where("signing_key IS NULL OR signing_key = ''").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, r := range rows {
+			updates := map[string]any{}
+			for column, value := range map[string]string{"signing_key": r.SecretHash, "previous_signing_key": r.PreviousSecretHash} {
+				if value == "" {
+					continue
+				}
+				sealed, err := utility.EncryptString(value)
+				if err != nil {
+					return err
+				}
+				updates[column] = sealed
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := tx.Table("machine_clients").Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, column := range []string{"secret_hash", "previous_secret_hash"} {
+			if err := tx.Migrator().DropColumn(&models.MachineClient{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
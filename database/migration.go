@@ -6,6 +6,10 @@ import (
 )
 
 func MigrateDatabase() {
+	if err := dropPlateNumberUniqueConstraint(); err != nil {
+		log.Fatalf("Failed to drop plate number constraint: %v", err)
+	}
+
 	err := DB.AutoMigrate(
 		&models.User{},
 		&models.Profile{},
@@ -14,9 +18,94 @@ func MigrateDatabase() {
 		&models.VehicleActivity{},
 		&models.GuestVehicleActivity{},
 		&models.PendingVehicleExit{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.UserToken{},
+		&models.Security{},
+		&models.Invitation{},
+		&models.Permission{},
+		&models.RolePermission{},
+		&models.LoginAttempt{},
+		&models.AccountLockout{},
+		&models.RecoveryCode{},
+		&models.MachineClient{},
+		&models.MachineRequestNonce{},
+		&models.SigningKey{},
+		&models.Session{},
+		&models.UserIdentity{},
+		&models.OIDCAuthState{},
+		&models.AuditEvent{},
+		&models.ActivityChainHead{},
+		&models.ActivityCheckpoint{},
+		&models.PlateConflict{},
+		&models.VehicleTransfer{},
+		&models.VehicleOwnership{},
+		&models.VehicleDriver{},
+		&models.VehicleAttachment{},
+		&models.VehicleType{},
 	)
 
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+
+	if err := encryptMachineClientKeys(); err != nil {
+		log.Fatalf("Failed to encrypt machine client keys: %v", err)
+	}
+
+	if err := encryptSigningKeys(); err != nil {
+		log.Fatalf("Failed to encrypt signing keys: %v", err)
+	}
+
+	if err := encryptTwoFactorSecrets(); err != nil {
+		log.Fatalf("Failed to encrypt two-factor secrets: %v", err)
+	}
+
+	if err := protectAuditEvents(); err != nil {
+		log.Fatalf("Failed to protect audit events: %v", err)
+	}
+
+	if err := normalizePlateNumbers(); err != nil {
+		log.Fatalf("Failed to normalize plate numbers: %v", err)
+	}
+}
+
+// dropPlateNumberUniqueConstraint lets an archived vehicle's plate be
+// registered again. Uniqueness among active vehicles is kept by the
+// normalized plate index. Older schemas used Postgres' default name for the
+// constraint, newer ones GORM's.
+func dropPlateNumberUniqueConstraint() error {
+	for _, constraint := range []string{"uni_vehicles_plate_number", "vehicles_plate_number_key"} {
+		if err := DB.Exec(`ALTER TABLE IF EXISTS vehicles DROP CONSTRAINT IF EXISTS ` + constraint).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protectAuditEvents makes audit_events append-only at the database level, so
+// rows cannot be changed even outside the application.
+func protectAuditEvents() error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`,
+		`CREATE TRIGGER audit_events_append_only
+			BEFORE UPDATE OR DELETE ON audit_events
+			FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()`,
+		`DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events`,
+		`CREATE TRIGGER audit_events_no_truncate
+			BEFORE TRUNCATE ON audit_events
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only()`,
+	}
+
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"log"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const plateNormalizationBatchSize = 1000
+
+// normalizePlateNumbers fills normalized_plate on rows written before the
+// column existed, archives active vehicles that collide once normalized and
+// then makes the normalized plate unique among active vehicles and indexes it
+// for fuzzy matching.
+func normalizePlateNumbers() error {
+	for _, table := range []string{"vehicles", "vehicle_activities", "guest_vehicle_activities"} {
+		if err := backfillNormalizedPlates(table); err != nil {
+			return err
+		}
+	}
+
+	if err := archiveDuplicateVehicles(); err != nil {
+		return err
+	}
+
+	if err := DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_vehicles_normalized_plate_active
+		ON vehicles (normalized_plate) WHERE deleted_at IS NULL AND normalized_plate <> ''`).Error; err != nil {
+		return err
+	}
+
+	return indexPlateSkeletons()
+}
+
+// indexPlateSkeletons adds the trigram index fuzzy plate matching searches,
+// over the plates with OCR confusions folded together.
+func indexPlateSkeletons() error {
+	if err := DB.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		return err
+	}
+
+	return DB.Exec(`CREATE INDEX IF NOT EXISTS idx_vehicles_plate_skeleton_trgm
+		ON vehicles USING gin ((` + utility.PlateSkeletonSQL("normalized_plate") + `) gin_trgm_ops)
+		WHERE deleted_at IS NULL`).Error
+}
+
+func backfillNormalizedPlates(table string) error {
+	type row struct {
+		ID          string
+		PlateNumber string
+	}
+
+	lastID := ""
+	for {
+		var rows []row
+		err := DB.Table(table).
+			Select("id", "plate_number").
+			Where("(normalized_plate IS NULL OR normalized_plate = '') AND id::text > ?", lastID).
+			Order("id::text asc").
+			Limit(plateNormalizationBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		err = DB.Transaction(func(tx *gorm.DB) error {
+			for _, r := range rows {
+				if err := tx.Table(table).Where("id = ?", r.ID).
+					UpdateColumn("normalized_plate", utility.NormalizePlateNumber(r.PlateNumber)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// archiveDuplicateVehicles keeps the earliest registration of each plate and
+// archives the others, recording each one in plate_conflicts for review.
+func archiveDuplicateVehicles() error {
+	var plates []string
+	err := DB.Model(&models.Vehicle{}).
+		Where("normalized_plate <> ''").
+		Group("normalized_plate").
+		Having("COUNT(*) > 1").
+		Pluck("normalized_plate", &plates).Error
+	if err != nil {
+		return err
+	}
+
+	for _, plate := range plates {
+		var vehicles []models.Vehicle
+		if err := DB.Where("normalized_plate = ?", plate).Order("created_at asc, id asc").Find(&vehicles).Error; err != nil {
+			return err
+		}
+		if len(vehicles) < 2 {
+			continue
+		}
+
+		kept := vehicles[0]
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for _, duplicate := range vehicles[1:] {
+				conflict := models.PlateConflict{
+					NormalizedPlate:      plate,
+					KeptVehicleID:        kept.ID,
+					KeptPlateNumber:      kept.PlateNumber,
+					KeptUserID:           kept.UserID,
+					DuplicateVehicleID:   duplicate.ID,
+					DuplicatePlateNumber: duplicate.PlateNumber,
+					DuplicateUserID:      duplicate.UserID,
+					Resolution:           models.PlateConflictSameOwner,
+				}
+				if duplicate.UserID != kept.UserID {
+					conflict.Resolution = models.PlateConflictOwnerMismatch
+				}
+				if err := tx.Create(&conflict).Error; err != nil {
+					return err
+				}
+
+				// archived rather than removed, so activity logs keep their vehicle
+				if err := tx.Model(&models.Vehicle{}).Where("id = ?", duplicate.ID).
+					UpdateColumn("deleted_at", time.Now()).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Archived %d duplicate vehicle(s) with plate %s, kept %s", len(vehicles)-1, plate, kept.ID)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"survielx-backend/models"
+	"survielx-backend/utility"
+
+	"gorm.io/gorm"
+)
+
+// encryptSigningKeys seals private signing keys that were stored as plaintext
+// PEM before keys were encrypted.
+func encryptSigningKeys() error {
+	var records []models.SigningKey
+	if err := DB.Find(&records).Error; err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, record := range records {
+			if utility.IsEncrypted(record.PrivateKey) {
+				continue
+			}
+
+			sealed, err := utility.EncryptString(record.PrivateKey)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.SigningKey{}).Where("kid = ?", record.KID).
+				Update("private_key", sealed).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"survielx-backend/models"
+	"survielx-backend/utility"
+
+	"gorm.io/gorm"
+)
+
+// encryptTwoFactorSecrets seals TOTP secrets that were stored as plaintext
+// before they were encrypted, so a database leak alone no longer yields
+// working second factors.
+func encryptTwoFactorSecrets() error {
+	var users []models.User
+	err := DB.Select("id", "two_factor_secret", "two_factor_pending_secret").
+		Where("two_factor_secret <> '' OR two_factor_pending_secret <> ''").
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			updates := map[string]any{}
+			for column, value := range map[string]string{
+				"two_factor_secret":         user.TwoFactorSecret,
+				"two_factor_pending_secret": user.TwoFactorPendingSecret,
+			} {
+				if value == "" || utility.IsEncrypted(value) {
+					continue
+				}
+				sealed, err := utility.EncryptString(value)
+				if err != nil {
+					return err
+				}
+				updates[column] = sealed
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
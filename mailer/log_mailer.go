@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogMailer is meant for local development: instead of delivering messages it
+// writes them to the log and optionally appends them to a file.
+type LogMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogMailer(path string) *LogMailer {
+	return &LogMailer{path: path}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	entry := fmt.Sprintf("To: %s\nSubject: %s\nDate: %s\n\n%s\n",
+		strings.Join(msg.To, ", "), msg.Subject, time.Now().Format(time.RFC1123Z), msg.Body)
+
+	if m.path == "" {
+		log.Println("Sending email:\n" + entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail log: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(entry + "----\n"); err != nil {
+		return fmt.Errorf("failed to write mail log: %v", err)
+	}
+
+	log.Printf("Email %q to %s written to %s", msg.Subject, strings.Join(msg.To, ", "), m.path)
+	return nil
+}
@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default is the mailer used by the services. It logs messages until Init
+// configures the driver selected through MAIL_DRIVER.
+var Default Mailer = NewLogMailer("")
+
+// Init selects the mail driver from the environment:
+//
+//	MAIL_DRIVER=log   writes messages to the application log and, if set, MAIL_LOG_FILE
+//	MAIL_DRIVER=smtp  delivers through SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM
+func Init() {
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		Default = NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			port,
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("MAIL_FROM"),
+		)
+	case "", "log":
+		Default = NewLogMailer(os.Getenv("MAIL_LOG_FILE"))
+	default:
+		log.Printf("Unknown MAIL_DRIVER %q, falling back to log mailer", os.Getenv("MAIL_DRIVER"))
+		Default = NewLogMailer(os.Getenv("MAIL_LOG_FILE"))
+	}
+}
+
+// Send delivers a message through the Default mailer.
+func Send(msg Message) error {
+	return Default.Send(msg)
+}
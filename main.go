@@ -5,8 +5,12 @@ import (
 	"log"
 	"os"
 	"survielx-backend/database"
+	"survielx-backend/mailer"
 	"survielx-backend/models/seed"
 	"survielx-backend/routers"
+	"survielx-backend/services"
+	"survielx-backend/storage"
+	"survielx-backend/utility"
 
 	"github.com/joho/godotenv"
 )
@@ -17,9 +21,40 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	if err := utility.CheckEncryptionKey(); err != nil {
+		log.Fatal("Invalid data encryption key: ", err)
+	}
+
+	mailer.Init()
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to configure blob storage: ", err)
+	}
+
 	database.ConnectDatabase()
 	database.MigrateDatabase()
 	seed.SeedAccessPoint(database.DB)
+	seed.SeedPermissions(database.DB)
+	seed.SeedVehicleTypes(database.DB)
+	if err := services.InitVehicleTypeCache(database.DB); err != nil {
+		log.Fatal("Failed to load vehicle types: ", err)
+	}
+	seed.SeedAdmin(database.DB)
+	if err := services.InitKeyManager(database.DB); err != nil {
+		log.Fatal("Failed to initialize token signing keys: ", err)
+	}
+	services.StartKeyRotation()
+	if err := services.BackfillActivityChains(database.DB); err != nil {
+		log.Fatal("Failed to link existing activity records: ", err)
+	}
+	services.StartActivityCheckpoints(database.DB)
+	if err := services.InitOIDCProviders(); err != nil {
+		log.Fatal("Failed to load OIDC providers: ", err)
+	}
+	if err := services.InitPlateCountries(); err != nil {
+		log.Fatal("Failed to load plate validators: ", err)
+	}
+	services.InitLoginAttemptStore(database.DB)
+	services.StartRevocationCleanup(database.DB)
 
 	r := routers.SetupRouter()
 
@@ -1,9 +1,10 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strings"
+	"survielx-backend/database"
 	"survielx-backend/services"
 	"survielx-backend/utility"
 
@@ -27,40 +28,28 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
+		claims, err := services.AuthenticateAccessToken(database.DB, tokenString)
 		if err != nil {
-			if err == jwt.ErrTokenExpired {
-				// Attempt to refresh the token
-				newToken, err := services.RefreshToken(tokenString)
-				if err != nil {
-					rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "Failed to refresh token", err.Error(), nil)
-					c.AbortWithStatusJSON(http.StatusUnauthorized, rd)
-					return
-				}
-				c.Header("X-Refreshed-Token", newToken)
-				claims, _ := token.Claims.(jwt.MapClaims)
-				c.Set("user_id", claims["sub"])
-				c.Next()
+			if errors.Is(err, services.ErrAccountSuspended) {
+				rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Account suspended", err.Error(), nil)
+				c.AbortWithStatusJSON(http.StatusForbidden, rd)
+				return
+			}
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "Token expired", "use the refresh token to obtain a new access token", nil)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, rd)
 				return
-
 			}
 			rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "Invalid token", err.Error(), nil)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, rd)
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("user_id", claims["sub"])
-			c.Next()
-		} else {
-			rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "Invalid token", nil, nil)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, rd)
-		}
+		sessionID, _ := claims["sid"].(string)
+		services.TouchSession(database.DB, sessionID)
+
+		c.Set("user_id", claims["sub"].(string))
+		c.Set("token_claims", claims)
+		c.Next()
 	}
 }
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MachineAuthMiddleware authenticates HMAC-signed requests from machine
+// clients and requires the client to hold the given scope.
+func MachineAuthMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusBadRequest, "error", "Failed to read request body", err.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusBadRequest, rd)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		client, code, err := services.AuthenticateMachineRequest(
+			database.DB,
+			c.GetHeader("X-Api-Key-Id"),
+			c.GetHeader("X-Timestamp"),
+			c.GetHeader("X-Nonce"),
+			c.GetHeader("X-Signature"),
+			c.Request.Method,
+			c.Request.URL.RequestURI(),
+			body,
+		)
+		if err != nil {
+			rd := utility.BuildErrorResponse(code, "error", "Unauthorized machine client", err.Error(), nil)
+			c.AbortWithStatusJSON(code, rd)
+			return
+		}
+
+		if !client.HasScope(scope) {
+			rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Forbidden", "Machine client is not allowed to perform this action", nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, rd)
+			return
+		}
+
+		c.Set("machine_client", client)
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"survielx-backend/database"
+	"survielx-backend/services"
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission only lets the request through when the authenticated
+// user's role has been granted all of the given permissions. Roles that must
+// use two-factor authentication are refused until the user has enrolled.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("user_id").(string)
+		user, err := services.GetUserByID(userID)
+		if err != nil {
+			rd := utility.BuildErrorResponse(http.StatusUnauthorized, "error", "Unauthorized", "Invalid user", nil)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, rd)
+			return
+		}
+
+		if services.TwoFactorRequiredForRole(user.Role) && !user.IsTwoFactorEnabled() {
+			rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Forbidden", "Two-factor authentication must be enabled for your role", nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, rd)
+			return
+		}
+
+		allowed, err := services.RoleHasPermissions(database.DB, user.Role, permissions...)
+		if err != nil {
+			log.Default().Println("Error checking permissions:", err)
+			rd := utility.BuildErrorResponse(http.StatusInternalServerError, "error", "Failed to check permissions", err.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, rd)
+			return
+		}
+
+		if !allowed {
+			rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Forbidden", "You do not have permission to perform this action", nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, rd)
+			return
+		}
+
+		c.Set("user_role", user.Role)
+		c.Next()
+	}
+}
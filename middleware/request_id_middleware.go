@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"survielx-backend/utility"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware tags every request with an ID, reusing the caller's
+// X-Request-ID when it is a UUID, and echoes it in the response.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if utility.ValidateUUID(requestID) != nil {
+			requestID = utility.GenerateUUID()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
@@ -8,8 +8,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SecurityMiddleware() gin.HandlerFunc {
+// VerifiedEmailMiddleware rejects users who have not verified their email when
+// REQUIRE_EMAIL_VERIFICATION is enabled.
+func VerifiedEmailMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !services.EmailVerificationRequired() {
+			c.Next()
+			return
+		}
+
 		userID := c.MustGet("user_id").(string)
 		user, err := services.GetUserByID(userID)
 		if err != nil {
@@ -18,12 +25,12 @@ func SecurityMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if user.Role != "security" {
-			rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Forbidden", "Security access required", nil)
+		if !user.IsEmailVerified() {
+			rd := utility.BuildErrorResponse(http.StatusForbidden, "error", "Forbidden", "Email verification required", nil)
 			c.AbortWithStatusJSON(http.StatusForbidden, rd)
 			return
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -30,6 +30,7 @@ type PendingVehicleExit struct {
 	Timestamp     time.Time `json:"timestamp" gorm:"column:timestamp"`
 	Status        string    `json:"status" gorm:"column:status"` // e.g., "pending", "approved", "denied"
 	ResponseToken string    // Optional: Unique token for secure response validation
+	RespondedByID *string   `json:"respondedById,omitempty" gorm:"column:responded_by_id;type:uuid"` // the driver whose answer resolved it
 }
 
 type PendingUpdateReq struct {
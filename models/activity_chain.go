@@ -0,0 +1,171 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ActivityChainVehicle = "vehicle_activities"
+	ActivityChainGuest   = "guest_vehicle_activities"
+)
+
+var ActivityChains = []string{ActivityChainVehicle, ActivityChainGuest}
+
+// ChainLink ties an activity record to the one logged before it. Hash covers
+// the record's content and PrevHash, so editing or removing any record breaks
+// every link after it.
+type ChainLink struct {
+	ChainSequence int64  `json:"chain_sequence" gorm:"column:chain_sequence;index"`
+	PrevHash      string `json:"prev_hash" gorm:"column:prev_hash"`
+	Hash          string `json:"hash" gorm:"column:hash;index"`
+}
+
+// ActivityChainHead is the latest link of a chain. Appends lock it so
+// concurrent inserts get consecutive sequence numbers.
+type ActivityChainHead struct {
+	Chain     string    `gorm:"column:chain;primaryKey" json:"chain"`
+	Sequence  int64     `gorm:"column:sequence;not null;default:0" json:"sequence"`
+	Hash      string    `gorm:"column:hash" json:"hash"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// ActivityCheckpoint is a signed statement of a chain's head at a point in
+// time. Signature is a JWS made with the token signing key.
+type ActivityCheckpoint struct {
+	ID        string    `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	Chain     string    `gorm:"column:chain;not null;index:idx_activity_checkpoint_chain_sequence" json:"chain"`
+	Sequence  int64     `gorm:"column:sequence;not null;index:idx_activity_checkpoint_chain_sequence" json:"sequence"`
+	Hash      string    `gorm:"column:hash;not null" json:"hash"`
+	Signature string    `gorm:"column:signature;type:text;not null" json:"signature"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ac *ActivityCheckpoint) BeforeCreate(tx *gorm.DB) (err error) {
+	ac.ID = utility.GenerateUUID()
+	return
+}
+
+type ChainBreak struct {
+	Sequence int64  `json:"sequence"`
+	RecordID string `json:"record_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+type ChainVerificationReport struct {
+	Chain              string      `json:"chain"`
+	From               *time.Time  `json:"from,omitempty"`
+	To                 *time.Time  `json:"to,omitempty"`
+	FirstSequence      int64       `json:"first_sequence"`
+	LastSequence       int64       `json:"last_sequence"`
+	RecordsChecked     int64       `json:"records_checked"`
+	CheckpointsChecked int         `json:"checkpoints_checked"`
+	Valid              bool        `json:"valid"`
+	FirstBreak         *ChainBreak `json:"first_break,omitempty"`
+}
+
+// vehicleActivityContent is the part of a VehicleActivity covered by its hash.
+type vehicleActivityContent struct {
+	ID           string      `json:"id"`
+	PlateNumber  string      `json:"plate_number"`
+	Model        string      `json:"model"`
+	VisitorType  VisitorType `json:"visitor_type"`
+	VehicleID    *string     `json:"vehicle_id"`
+	IsEntry      bool        `json:"is_entry"`
+	VehicleType  string      `json:"vehicle_type"`
+	EntryPointID *string     `json:"entry_point_id"`
+	ExitPointID  *string     `json:"exit_point_id"`
+	GateName     string      `json:"gate_name"`
+	Timestamp    string      `json:"timestamp"`
+}
+
+type guestVehicleActivityContent struct {
+	ID           string  `json:"id"`
+	PlateNumber  string  `json:"plate_number"`
+	IsEntry      bool    `json:"is_entry"`
+	EntryPointID *string `json:"entry_point_id"`
+	ExitPointID  *string `json:"exit_point_id"`
+	Timestamp    string  `json:"timestamp"`
+}
+
+func (va *VehicleActivity) ChainContent() any {
+	return vehicleActivityContent{
+		ID:           va.ID,
+		PlateNumber:  va.PlateNumber,
+		Model:        va.Model,
+		VisitorType:  va.VisitorType,
+		VehicleID:    va.VehicleID,
+		IsEntry:      va.IsEntry,
+		VehicleType:  va.VehicleType,
+		EntryPointID: va.EntryPointID,
+		ExitPointID:  va.ExitPointID,
+		GateName:     va.GateName,
+		Timestamp:    chainTimestamp(va.Timestamp),
+	}
+}
+
+func (va *GuestVehicleActivity) ChainContent() any {
+	return guestVehicleActivityContent{
+		ID:           va.ID,
+		PlateNumber:  va.PlateNumber,
+		IsEntry:      va.IsEntry,
+		EntryPointID: va.EntryPointID,
+		ExitPointID:  va.ExitPointID,
+		Timestamp:    chainTimestamp(va.Timestamp),
+	}
+}
+
+// chainTimestamp formats a timestamp the way it reads back from Postgres,
+// which keeps microseconds.
+func chainTimestamp(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// ChainHash computes the hash of a record at the given position.
+func ChainHash(chain string, sequence int64, prevHash string, content any) (string, error) {
+	payload, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(chain + "\n" + strconv.FormatInt(sequence, 10) + "\n" + prevHash + "\n"))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// AppendToChain links a record after the current head of the chain and moves
+// the head forward. It must run in the transaction that stores the record.
+func AppendToChain(tx *gorm.DB, chain string, link *ChainLink, content any) error {
+	db := tx.Session(&gorm.Session{NewDB: true})
+
+	head := ActivityChainHead{Chain: chain}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&head).Error; err != nil {
+		return err
+	}
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("chain = ?", chain).First(&head).Error; err != nil {
+		return err
+	}
+
+	sequence, prevHash := head.Sequence+1, head.Hash
+	hash, err := ChainHash(chain, sequence, prevHash, content)
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(&head).Updates(map[string]any{"sequence": sequence, "hash": hash}).Error; err != nil {
+		return err
+	}
+
+	link.ChainSequence = sequence
+	link.PrevHash = prevHash
+	link.Hash = hash
+	return nil
+}
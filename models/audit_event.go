@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AuditActorUser    = "user"
+	AuditActorMachine = "machine"
+	AuditActorSystem  = "system"
+)
+
+var ErrAuditEventImmutable = errors.New("audit events cannot be changed or deleted")
+
+// AuditActor identifies who performed an audited change and the request it
+// was made in.
+type AuditActor struct {
+	Type      string
+	ID        string
+	IPAddress string
+	RequestID string
+}
+
+// SystemActor is the actor of changes made by the application itself, such as
+// timeouts and background jobs.
+func SystemActor() AuditActor {
+	return AuditActor{Type: AuditActorSystem}
+}
+
+// UserActor is the actor of changes a user made outside an HTTP request, for
+// example over the WebSocket.
+func UserActor(userID string) AuditActor {
+	return AuditActor{Type: AuditActorUser, ID: userID}
+}
+
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditEvent records a state-changing operation. Rows are append-only; the
+// database rejects updates and deletes.
+type AuditEvent struct {
+	ID         string                 `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	ActorType  string                 `gorm:"column:actor_type;not null" json:"actor_type"`
+	ActorID    string                 `gorm:"column:actor_id;index" json:"actor_id,omitempty"`
+	Action     string                 `gorm:"column:action;not null;index" json:"action"`
+	TargetType string                 `gorm:"column:target_type;not null;index:idx_audit_target" json:"target_type"`
+	TargetID   string                 `gorm:"column:target_id;index:idx_audit_target" json:"target_id"`
+	Changes    map[string]AuditChange `gorm:"column:changes;type:jsonb;serializer:json" json:"changes,omitempty"`
+	IPAddress  string                 `gorm:"column:ip_address" json:"ip_address,omitempty"`
+	RequestID  string                 `gorm:"column:request_id;index" json:"request_id,omitempty"`
+	CreatedAt  time.Time              `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (ae *AuditEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	ae.ID = utility.GenerateUUID()
+	return
+}
+
+func (ae *AuditEvent) BeforeUpdate(tx *gorm.DB) (err error) {
+	return ErrAuditEventImmutable
+}
+
+func (ae *AuditEvent) BeforeDelete(tx *gorm.DB) (err error) {
+	return ErrAuditEventImmutable
+}
+
+type AuditFilters struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
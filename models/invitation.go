@@ -0,0 +1,47 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invitation lets an admin provision a privileged (security or admin) account.
+// The invitee redeems the emailed token to create their user.
+type Invitation struct {
+	ID                string           `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	Email             string           `gorm:"column:email;not null;index" json:"email"`
+	Role              string           `gorm:"column:role;not null" json:"role"`
+	TokenHash         string           `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	AccessExitPointID *string          `gorm:"column:access_exit_point_id;type:uuid" json:"access_exit_point_id,omitempty"`
+	AccessExitPoint   *AccessExitPoint `gorm:"foreignKey:AccessExitPointID" json:"access_exit_point,omitempty"`
+	InvitedByID       string           `gorm:"column:invited_by_id;type:uuid" json:"invited_by_id"`
+	AcceptedUserID    *string          `gorm:"column:accepted_user_id;type:uuid" json:"accepted_user_id,omitempty"`
+	ExpiresAt         time.Time        `gorm:"column:expires_at;not null" json:"expires_at"`
+	AcceptedAt        *time.Time       `gorm:"column:accepted_at" json:"accepted_at,omitempty"`
+	RevokedAt         *time.Time       `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt         time.Time        `gorm:"column:created_at" json:"created_at"`
+}
+
+func (inv *Invitation) BeforeCreate(tx *gorm.DB) (err error) {
+	inv.ID = utility.GenerateUUID()
+	return
+}
+
+// IsPending reports whether the invitation can still be redeemed.
+func (inv *Invitation) IsPending() bool {
+	return inv.AcceptedAt == nil && inv.RevokedAt == nil && time.Now().Before(inv.ExpiresAt)
+}
+
+type CreateInvitationInput struct {
+	Email             string `json:"email" validate:"required,email"`
+	Role              string `json:"role" validate:"required,oneof=admin security"`
+	AccessExitPointID string `json:"access_exit_point_id" validate:"omitempty,uuid"`
+}
+
+type AcceptInvitationInput struct {
+	Token    string `json:"token" validate:"required"`
+	Name     string `json:"name" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
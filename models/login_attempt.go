@@ -0,0 +1,49 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt tracks consecutive failed logins for a key such as an account
+// email or a client IP. It backs the Postgres login attempt store.
+type LoginAttempt struct {
+	Key            string     `gorm:"column:key;primaryKey" json:"key"`
+	FailureCount   int        `gorm:"column:failure_count;not null;default:0" json:"failure_count"`
+	FirstFailureAt time.Time  `gorm:"column:first_failure_at" json:"first_failure_at"`
+	LastFailureAt  time.Time  `gorm:"column:last_failure_at" json:"last_failure_at"`
+	LockedUntil    *time.Time `gorm:"column:locked_until" json:"locked_until,omitempty"`
+}
+
+type LockoutScope string
+
+const (
+	LockoutScopeAccount LockoutScope = "account"
+	LockoutScopeIP      LockoutScope = "ip"
+)
+
+// AccountLockout is the audit record written whenever an account or client IP
+// is locked out after too many failed logins.
+type AccountLockout struct {
+	ID           string       `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	Scope        LockoutScope `gorm:"column:scope;type:varchar(20);not null;index" json:"scope"`
+	UserID       *string      `gorm:"column:user_id;type:uuid;index" json:"user_id,omitempty"`
+	Email        string       `gorm:"column:email;index" json:"email"`
+	IPAddress    string       `gorm:"column:ip_address;index" json:"ip_address"`
+	FailureCount int          `gorm:"column:failure_count" json:"failure_count"`
+	LockedUntil  time.Time    `gorm:"column:locked_until" json:"locked_until"`
+	UnlockedAt   *time.Time   `gorm:"column:unlocked_at" json:"unlocked_at,omitempty"`
+	UnlockedByID *string      `gorm:"column:unlocked_by_id;type:uuid" json:"unlocked_by_id,omitempty"`
+	CreatedAt    time.Time    `gorm:"column:created_at" json:"created_at"`
+}
+
+func (l *AccountLockout) BeforeCreate(tx *gorm.DB) (err error) {
+	l.ID = utility.GenerateUUID()
+	return
+}
+
+type UnlockAccountInput struct {
+	IPAddress string `json:"ip_address" validate:"omitempty,ip"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"slices"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scopes that can be granted to a machine client.
+const (
+	MachineScopeVehiclesIdentify = "vehicles:identify"
+	MachineScopeVehiclesLog      = "vehicles:log"
+)
+
+// MachineClient is a non-human caller, such as the ANPR model backend, that
+// authenticates with HMAC-signed requests instead of a user token.
+// SigningKey is the HMAC key the client signs with, the hex SHA-256 digest of
+// its secret, sealed with utility.EncryptString so the database alone is not
+// enough to sign requests.
+type MachineClient struct {
+	ID                      string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	Name                    string     `gorm:"column:name;not null" json:"name"`
+	KeyID                   string     `gorm:"column:key_id;not null;uniqueIndex" json:"key_id"`
+	SigningKey              string     `gorm:"column:signing_key" json:"-"`
+	PreviousSigningKey      string     `gorm:"column:previous_signing_key" json:"-"`
+	PreviousSecretExpiresAt *time.Time `gorm:"column:previous_secret_expires_at" json:"-"`
+	AllowedGates            []string   `gorm:"column:allowed_gates;serializer:json" json:"allowed_gates"`
+	Scopes                  []string   `gorm:"column:scopes;serializer:json" json:"scopes"`
+	CreatedByID             string     `gorm:"column:created_by_id;type:uuid" json:"created_by_id"`
+	Secret                  string     `gorm:"-" json:"secret,omitempty"`
+	LastUsedAt              *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+	RotatedAt               *time.Time `gorm:"column:rotated_at" json:"rotated_at,omitempty"`
+	RevokedAt               *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt               time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt               time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (mc *MachineClient) BeforeCreate(tx *gorm.DB) (err error) {
+	mc.ID = utility.GenerateUUID()
+	return
+}
+
+func (mc *MachineClient) HasScope(scope string) bool {
+	return slices.Contains(mc.Scopes, scope)
+}
+
+// AllowsGate reports whether the client may act on behalf of the gate. A
+// client without gate restrictions may use any gate.
+func (mc *MachineClient) AllowsGate(gateID string) bool {
+	return len(mc.AllowedGates) == 0 || slices.Contains(mc.AllowedGates, gateID)
+}
+
+// MachineRequestNonce remembers nonces of signed requests until their
+// timestamp falls outside the accepted window, so a request cannot be replayed.
+type MachineRequestNonce struct {
+	KeyID     string    `gorm:"column:key_id;primaryKey" json:"key_id"`
+	Nonce     string    `gorm:"column:nonce;primaryKey" json:"nonce"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type CreateMachineClientInput struct {
+	Name         string   `json:"name" validate:"required"`
+	AllowedGates []string `json:"allowed_gates" validate:"omitempty,dive,uuid"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=vehicles:identify vehicles:log"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect
+// provider.
+type UserIdentity struct {
+	ID          string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	UserID      string     `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	Provider    string     `gorm:"column:provider;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"column:subject;not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email       string     `gorm:"column:email" json:"email"`
+	LastLoginAt *time.Time `gorm:"column:last_login_at" json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ui *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	ui.ID = utility.GenerateUUID()
+	return
+}
+
+// OIDCAuthState holds the PKCE verifier and nonce of an authorization request
+// until the provider redirects back with the matching state.
+type OIDCAuthState struct {
+	StateHash    string    `gorm:"column:state_hash;primaryKey" json:"-"`
+	Provider     string    `gorm:"column:provider;not null" json:"provider"`
+	CodeVerifier string    `gorm:"column:code_verifier;not null" json:"-"`
+	Nonce        string    `gorm:"column:nonce;not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+type OIDCProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type OIDCCallbackInput struct {
+	Code  string `form:"code" json:"code" validate:"required"`
+	State string `form:"state" json:"state" validate:"required"`
+}
@@ -32,6 +32,11 @@ type PaginatedPendingVehicleResponse struct {
 	Pagination PaginationResponse `json:"pagination"`
 }
 
+type PaginatedResponse struct {
+	Data       interface{}        `json:"data"`
+	Pagination PaginationResponse `json:"pagination"`
+}
+
 func GetPagination(c *gin.Context) Pagination {
 	var (
 		page  *int
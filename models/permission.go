@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+const (
+	PermissionVehiclesReadAny    = "vehicles:read_any"
+	PermissionVehiclesLog        = "vehicles:log"
+	PermissionGatesManage        = "gates:manage"
+	PermissionUsersRead          = "users:read"
+	PermissionUsersManage        = "users:manage"
+	PermissionReportsExport      = "reports:export"
+	PermissionInvitationsManage  = "invitations:manage"
+	PermissionRolesManage        = "roles:manage"
+	PermissionMachinesManage     = "machine_clients:manage"
+	PermissionAuditRead          = "audit:read"
+	PermissionTransfersApprove   = "vehicle_transfers:approve"
+	PermissionVehiclesReview     = "vehicles:review"
+	PermissionVehicleTypesManage = "vehicle_types:manage"
+	PermissionVehiclesRestore    = "vehicles:restore"
+	PermissionVehiclesBulk       = "vehicles:bulk"
+)
+
+// Roles lists every role a user can hold.
+var Roles = []string{RoleUser, RoleSecurity, RoleAdmin}
+
+// DefaultPermissions describes every permission known to the application.
+var DefaultPermissions = map[string]string{
+	PermissionVehiclesReadAny:    "View any vehicle, its activity logs and owner profile",
+	PermissionVehiclesLog:        "Log vehicle entries and exits at a gate",
+	PermissionGatesManage:        "Create, update and delete access/exit points",
+	PermissionUsersRead:          "List users",
+	PermissionUsersManage:        "Manage user accounts",
+	PermissionReportsExport:      "Generate and export activity reports",
+	PermissionInvitationsManage:  "Invite security staff and admins",
+	PermissionRolesManage:        "Change which permissions each role has",
+	PermissionMachinesManage:     "Register, rotate and revoke machine API keys",
+	PermissionAuditRead:          "View the audit trail",
+	PermissionTransfersApprove:   "Approve or reject vehicle ownership transfers",
+	PermissionVehiclesReview:     "Approve or reject vehicle registrations",
+	PermissionVehicleTypesManage: "Manage the catalog of vehicle types",
+	PermissionVehiclesRestore:    "Restore archived vehicles or re-register them to another user",
+	PermissionVehiclesBulk:       "Import and export vehicles in bulk as CSV",
+}
+
+// DefaultRolePermissions is granted to a role when a permission is first
+// created. Admins receive every permission.
+var DefaultRolePermissions = map[string][]string{
+	RoleSecurity: {
+		PermissionVehiclesReadAny,
+		PermissionVehiclesLog,
+		PermissionGatesManage,
+		PermissionUsersRead,
+		PermissionReportsExport,
+		PermissionTransfersApprove,
+		PermissionVehiclesReview,
+	},
+}
+
+type Permission struct {
+	Name        string    `gorm:"column:name;primaryKey" json:"name"`
+	Description string    `gorm:"column:description" json:"description"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type RolePermission struct {
+	Role           string    `gorm:"column:role;primaryKey" json:"role"`
+	PermissionName string    `gorm:"column:permission_name;primaryKey" json:"permission_name"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type UpdateRolePermissionsInput struct {
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
+}
+
+type RolePermissionsResponse struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// the owner registered the same plate twice and keeps the earlier record
+	PlateConflictSameOwner = "same_owner"
+	// another user registered the plate first, an admin has to decide who owns it
+	PlateConflictOwnerMismatch = "owner_mismatch"
+)
+
+// PlateConflict records an active vehicle that was archived because another
+// vehicle already had the same normalized plate number.
+type PlateConflict struct {
+	ID                   string    `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	NormalizedPlate      string    `gorm:"column:normalized_plate;not null;index" json:"normalized_plate"`
+	KeptVehicleID        string    `gorm:"column:kept_vehicle_id;type:uuid;not null" json:"kept_vehicle_id"`
+	KeptPlateNumber      string    `gorm:"column:kept_plate_number" json:"kept_plate_number"`
+	KeptUserID           string    `gorm:"column:kept_user_id;type:uuid" json:"kept_user_id"`
+	DuplicateVehicleID   string    `gorm:"column:duplicate_vehicle_id;type:uuid;not null;uniqueIndex" json:"duplicate_vehicle_id"`
+	DuplicatePlateNumber string    `gorm:"column:duplicate_plate_number" json:"duplicate_plate_number"`
+	DuplicateUserID      string    `gorm:"column:duplicate_user_id;type:uuid" json:"duplicate_user_id"`
+	Resolution           string    `gorm:"column:resolution;not null" json:"resolution"`
+	CreatedAt            time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (pc *PlateConflict) BeforeCreate(tx *gorm.DB) (err error) {
+	pc.ID = utility.GenerateUUID()
+	return
+}
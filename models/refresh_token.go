@@ -0,0 +1,29 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a persisted, single-use refresh token. Every rotation issues
+// a new token in the same family; presenting an already rotated token revokes
+// the whole family.
+type RefreshToken struct {
+	ID           string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	UserID       string     `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	FamilyID     string     `gorm:"column:family_id;type:uuid;not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	ReplacedByID *string    `gorm:"column:replaced_by_id;type:uuid" json:"replaced_by_id,omitempty"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	RevokedAt    *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (rt *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if rt.ID == "" {
+		rt.ID = utility.GenerateUUID()
+	}
+	return
+}
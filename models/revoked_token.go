@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RevokedToken records an access token (by its jti) that must be rejected
+// before its natural expiry. Rows can be purged once ExpiresAt has passed.
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;type:uuid;primaryKey;" json:"jti"`
+	UserID    string    `gorm:"column:user_id;type:uuid;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
@@ -8,11 +8,13 @@ import (
 )
 
 type Security struct {
-	ID        string         `gorm:"type:uuid;primary_key;"`
-	UserID    string         `gorm:"type:uuid;unique"`
-	User      User           `gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"createdAt"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
+	ID                string           `gorm:"type:uuid;primary_key;"`
+	UserID            string           `gorm:"type:uuid;unique"`
+	User              User             `gorm:"foreignKey:UserID"`
+	AccessExitPointID *string          `gorm:"column:access_exit_point_id;type:uuid" json:"accessExitPointId,omitempty"` // gate the guard is assigned to
+	AccessExitPoint   *AccessExitPoint `gorm:"foreignKey:AccessExitPointID" json:"accessExitPoint,omitempty"`
+	CreatedAt         time.Time        `json:"createdAt"`
+	DeletedAt         gorm.DeletedAt   `gorm:"index" json:"deletedAt"`
 }
 
 func (security *Security) BeforeCreate(tx *gorm.DB) (err error) {
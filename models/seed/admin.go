@@ -0,0 +1,63 @@
+package seed
+
+import (
+	"fmt"
+	"os"
+	"survielx-backend/models"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// SeedAdmin bootstraps the first admin from ADMIN_EMAIL and ADMIN_PASSWORD.
+// Further admins and security staff are provisioned through invitations.
+func SeedAdmin(db *gorm.DB) {
+	email := os.Getenv("ADMIN_EMAIL")
+	password := os.Getenv("ADMIN_PASSWORD")
+	if email == "" || password == "" {
+		return
+	}
+
+	var count int64
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&count).Error; err != nil {
+		fmt.Println("admin seeding: " + err.Error())
+		return
+	}
+
+	if count > 0 {
+		fmt.Println("Admin already exists, skipping seeding...")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Println("admin seeding: " + err.Error())
+		return
+	}
+
+	name := os.Getenv("ADMIN_NAME")
+	if name == "" {
+		name = "Administrator"
+	}
+
+	now := time.Now()
+	admin := models.User{
+		Name:            name,
+		Email:           email,
+		Password:        string(hashedPassword),
+		Role:            models.RoleAdmin,
+		EmailVerifiedAt: &now,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := admin.CreateUser(tx); err != nil {
+			return err
+		}
+		profile := models.Profile{UserID: admin.ID, FullName: admin.Name}
+		return profile.CreateProfile(tx)
+	})
+	if err != nil {
+		fmt.Println("failed to seed admin: " + err.Error())
+	}
+}
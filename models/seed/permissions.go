@@ -0,0 +1,43 @@
+package seed
+
+import (
+	"fmt"
+	"survielx-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedPermissions inserts permissions that do not exist yet and grants each
+// newly created permission to its default roles. Mappings edited by admins
+// for existing permissions are left untouched.
+func SeedPermissions(db *gorm.DB) {
+	for name, description := range models.DefaultPermissions {
+		permission := models.Permission{Name: name, Description: description}
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&permission)
+		if result.Error != nil {
+			fmt.Println("permission seeding: " + result.Error.Error())
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		roles := []string{models.RoleAdmin}
+		for role, permissions := range models.DefaultRolePermissions {
+			for _, p := range permissions {
+				if p == name {
+					roles = append(roles, role)
+				}
+			}
+		}
+
+		for _, role := range roles {
+			rp := models.RolePermission{Role: role, PermissionName: name}
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rp).Error; err != nil {
+				fmt.Println("failed to seed role permission: " + err.Error())
+			}
+		}
+	}
+}
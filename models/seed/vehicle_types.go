@@ -0,0 +1,21 @@
+package seed
+
+import (
+	"fmt"
+	"survielx-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedVehicleTypes adds the default vehicle types that are missing from the
+// catalog. Types already present, including ones admins have edited or
+// deactivated, are left untouched.
+func SeedVehicleTypes(db *gorm.DB) {
+	for _, vehicleType := range models.DefaultVehicleTypes {
+		vehicleType.Active = true
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&vehicleType).Error; err != nil {
+			fmt.Println("vehicle type seeding: " + err.Error())
+		}
+	}
+}
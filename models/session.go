@@ -0,0 +1,36 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is a signed-in device. Its ID doubles as the refresh token family
+// and is carried in access tokens as the "sid" claim. ConnectedAt is set while
+// the device holds a WebSocket open.
+type Session struct {
+	ID          string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	UserID      string     `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	DeviceLabel string     `gorm:"column:device_label" json:"device_label"`
+	IPAddress   string     `gorm:"column:ip_address" json:"ip_address"`
+	UserAgent   string     `gorm:"column:user_agent" json:"user_agent"`
+	LastSeenAt  time.Time  `gorm:"column:last_seen_at;not null;index" json:"last_seen_at"`
+	ConnectedAt *time.Time `gorm:"column:connected_at" json:"connected_at,omitempty"`
+	RevokedAt   *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	Current     bool       `gorm:"-" json:"current"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = utility.GenerateUUID()
+	return
+}
+
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	IPAddress   string
+	UserAgent   string
+	DeviceLabel string
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric key used to sign tokens. New tokens are signed
+// with the newest key until SignUntil; the public key stays published for
+// verification until VerifyUntil so tokens issued before a rotation remain valid.
+// PrivateKey holds the PEM encoded key sealed with utility.EncryptString.
+type SigningKey struct {
+	KID         string    `gorm:"column:kid;primaryKey" json:"kid"`
+	Algorithm   string    `gorm:"column:algorithm;not null" json:"algorithm"`
+	PrivateKey  string    `gorm:"column:private_key;not null" json:"-"`
+	PublicKey   string    `gorm:"column:public_key;not null" json:"public_key"`
+	SignUntil   time.Time `gorm:"column:sign_until;not null" json:"sign_until"`
+	VerifyUntil time.Time `gorm:"column:verify_until;not null;index" json:"verify_until"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// JWK is the public half of a signing key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -7,15 +7,34 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser     = "user"
+	RoleSecurity = "security"
+	RoleAdmin    = "admin"
+)
+
 type User struct {
-    ID        string         `gorm:"column:id;type:uuid;primaryKey;"`
-    Name      string         `json:"name" gorm:"column:name"`
-    Email     string         `json:"email" gorm:"column:email;unique"`
-    Password  string         `json:"-" gorm:"column:password"`
-    Role      string         `json:"role" gorm:"column:role;default:'user'"`
-    Token     string         `json:"token,omitempty" gorm:"column:token"`
-    CreatedAt time.Time      `json:"createdAt" gorm:"column:created_at"`
-    DeletedAt gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+	ID                     string     `gorm:"column:id;type:uuid;primaryKey;"`
+	Name                   string     `json:"name" gorm:"column:name"`
+	Email                  string     `json:"email" gorm:"column:email;unique"`
+	Password               string     `json:"-" gorm:"column:password"`
+	Role                   string     `json:"role" gorm:"column:role;default:'user'"`
+	Token                  string     `json:"token,omitempty" gorm:"column:token"`
+	RefreshToken           string     `json:"refresh_token,omitempty" gorm:"-"`
+	EmailVerifiedAt        *time.Time `json:"email_verified_at" gorm:"column:email_verified_at"`
+	TwoFactorEnabledAt     *time.Time `json:"two_factor_enabled_at" gorm:"column:two_factor_enabled_at"`
+	TwoFactorSecret        string     `json:"-" gorm:"column:two_factor_secret"`
+	TwoFactorPendingSecret string     `json:"-" gorm:"column:two_factor_pending_secret"`
+	TwoFactorLastStep      int64      `json:"-" gorm:"column:two_factor_last_step"`
+	// set on login responses when a second factor is still required
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty" gorm:"-"`
+	TwoFactorChallenge string `json:"two_factor_challenge,omitempty" gorm:"-"`
+	// TokensRevokedAt invalidates every access token issued before it (logout from all devices).
+	TokensRevokedAt  *time.Time     `json:"-" gorm:"column:tokens_revoked_at"`
+	SuspendedAt      *time.Time     `json:"suspended_at" gorm:"column:suspended_at"`
+	SuspensionReason string         `json:"suspension_reason,omitempty" gorm:"column:suspension_reason"`
+	CreatedAt        time.Time      `json:"createdAt" gorm:"column:created_at"`
+	DeletedAt        gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
 }
 
 func (user *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -23,9 +42,44 @@ func (user *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (user *User) IsEmailVerified() bool {
+	return user.EmailVerifiedAt != nil
+}
+
+func (user *User) IsTwoFactorEnabled() bool {
+	return user.TwoFactorEnabledAt != nil
+}
+
+func (user *User) IsSuspended() bool {
+	return user.SuspendedAt != nil
+}
+
 func (user *User) CreateUser(db *gorm.DB) error {
 	if err := db.Create(user).Error; err != nil {
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+type UserFilters struct {
+	Search string
+	Role   string
+	Email  string
+	Name   string
+	Status string
+}
+
+// UserDetailsResponse is an admin's view of a user.
+type UserDetailsResponse struct {
+	User     User      `json:"user"`
+	Profile  *Profile  `json:"profile"`
+	Vehicles []Vehicle `json:"vehicles"`
+}
+
+type UpdateUserRoleInput struct {
+	Role string `json:"role" validate:"required,oneof=user security admin"`
+}
+
+type SuspendUserInput struct {
+	Reason string `json:"reason" validate:"required"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserTokenPurpose string
+
+const (
+	UserTokenPurposePasswordReset     UserTokenPurpose = "password_reset"
+	UserTokenPurposeEmailVerification UserTokenPurpose = "email_verification"
+)
+
+// UserToken is a single-use, expiring token emailed to a user. Only the hash
+// of the token is stored.
+type UserToken struct {
+	ID        string           `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	UserID    string           `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	Purpose   UserTokenPurpose `gorm:"column:purpose;type:varchar(40);not null;index" json:"purpose"`
+	TokenHash string           `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time        `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt    *time.Time       `gorm:"column:used_at" json:"used_at,omitempty"`
+	CreatedAt time.Time        `gorm:"column:created_at" json:"created_at"`
+}
+
+func (t *UserToken) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = utility.GenerateUUID()
+	return
+}
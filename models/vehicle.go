@@ -8,26 +8,50 @@ import (
 	"survielx-backend/utility"
 )
 
+const (
+	VehicleStatusPendingReview = "pending_review"
+	VehicleStatusApproved      = "approved"
+	VehicleStatusRejected      = "rejected"
+)
+
+// Vehicle is a resident's registered vehicle. Only approved vehicles are
+// treated as registered at the gate; vehicles registered before reviews
+// existed default to approved. Deregistered vehicles are archived through
+// DeletedAt so their activity history keeps pointing at them.
 type Vehicle struct {
-	ID          string         `json:"id" gorm:"column:id;type:uuid;primaryKey;"`
-	UserID      string         `json:"user_id" gorm:"column:user_id;type:uuid;"`
-	PlateNumber string         `json:"plate_number" gorm:"column:plate_number;unique"`
-	Type        string         `json:"type" validate:"oneof=bus car bike" gorm:"column:type"`
-	Model       string         `json:"model" gorm:"column:model"`
-	Color       string         `json:"color" gorm:"column:color"`
-	CreatedAt   time.Time      `json:"createdAt" gorm:"column:created_at"`
-	DeletedAt   gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+	ID              string         `json:"id" gorm:"column:id;type:uuid;primaryKey;"`
+	UserID          string         `json:"user_id" gorm:"column:user_id;type:uuid;"`
+	PlateNumber     string         `json:"plate_number" gorm:"column:plate_number"`
+	NormalizedPlate string         `json:"normalized_plate" gorm:"column:normalized_plate;index"`
+	PlateCountry    string         `json:"plate_country,omitempty" gorm:"column:plate_country"`
+	PlateCategory   string         `json:"plate_category,omitempty" gorm:"column:plate_category"`
+	Type            string         `json:"type" validate:"vehicle_type" gorm:"column:type"`
+	Model           string         `json:"model" gorm:"column:model"`
+	Color           string         `json:"color" gorm:"column:color"`
+	Status          string         `json:"status" gorm:"column:status;not null;default:'approved';index"`
+	ReviewedByID    *string        `json:"reviewed_by_id,omitempty" gorm:"column:reviewed_by_id;type:uuid"`
+	ReviewedAt      *time.Time     `json:"reviewed_at,omitempty" gorm:"column:reviewed_at"`
+	ReviewReason    string         `json:"review_reason,omitempty" gorm:"column:review_reason"`
+	ArchivedByID    *string        `json:"archived_by_id,omitempty" gorm:"column:archived_by_id;type:uuid"`
+	ArchiveReason   string         `json:"archive_reason,omitempty" gorm:"column:archive_reason"`
+	CreatedAt       time.Time      `json:"createdAt" gorm:"column:created_at"`
+	DeletedAt       gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
+}
+
+func (vehicle *Vehicle) IsApproved() bool {
+	return vehicle.Status == VehicleStatusApproved
 }
 
 type VehicleInfo struct {
 	PlateNumber string `json:"plate_number,omitempty" gorm:"column:plate_number;uniqueIndex"`
-	Type        string `json:"type,omitempty" validate:"oneof=bus car bike" gorm:"column:type"`
+	Type        string `json:"type,omitempty" validate:"vehicle_type" gorm:"column:type"`
 	Model       string `json:"model,omitempty" gorm:"column:model"`
 	Color       string `json:"color,omitempty" gorm:"column:color"`
 }
 
 func (vehicle *Vehicle) BeforeCreate(tx *gorm.DB) (err error) {
 	vehicle.ID = utility.GenerateUUID()
+	vehicle.NormalizedPlate = utility.NormalizePlateNumber(vehicle.PlateNumber)
 	return
 }
 
@@ -39,17 +63,18 @@ const (
 )
 
 type VehicleActivity struct {
-	ID          string      `json:"id" gorm:"column:id;type:uuid;primary_key;"`
-	PlateNumber string      `json:"plate_number" gorm:"column:plate_number;not null;index"`
-	Model       string      `json:"model" gorm:"column:model;not null;index"`
-	VisitorType VisitorType `json:"visitor_type" gorm:"column:visitor_type;type:varchar(20);not null;index"`
+	ID              string      `json:"id" gorm:"column:id;type:uuid;primary_key;"`
+	PlateNumber     string      `json:"plate_number" gorm:"column:plate_number;not null;index"`
+	NormalizedPlate string      `json:"normalized_plate" gorm:"column:normalized_plate;index"`
+	Model           string      `json:"model" gorm:"column:model;not null;index"`
+	VisitorType     VisitorType `json:"visitor_type" gorm:"column:visitor_type;type:varchar(20);not null;index"`
 
 	Vehicle   *Vehicle `json:"vehicle,omitempty" gorm:"foreignKey:VehicleID"`
 	VehicleID *string  `json:"vehicle_id,omitempty" gorm:"column:vehicle_id;type:uuid;index"`
 
 	// Common fields
 	IsEntry     bool   `json:"is_entry" gorm:"column:is_entry"`
-	VehicleType string `json:"vehicle_type" gorm:"column:vehicle_type;type:varchar(20)" validate:"oneof=bus car bike"`
+	VehicleType string `json:"vehicle_type" gorm:"column:vehicle_type;type:varchar(20)" validate:"vehicle_type"`
 
 	// Entry/Exit points
 	EntryPointID *string          `json:"entry_point_id,omitempty" gorm:"column:entry_point_id;type:uuid"`
@@ -58,6 +83,8 @@ type VehicleActivity struct {
 	ExitPoint    *AccessExitPoint `json:"exit_point,omitempty" gorm:"foreignKey:ExitPointID"`
 	GateName     string           `json:"gate_name,omitempty" gorm:"column:gate_name"`
 
+	ChainLink
+
 	Timestamp time.Time      `json:"timestamp" gorm:"column:timestamp;not null;default:CURRENT_TIMESTAMP"`
 	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt time.Time      `json:"updated_at" gorm:"column:updated_at"`
@@ -65,8 +92,9 @@ type VehicleActivity struct {
 }
 
 type GuestVehicleActivity struct {
-	ID          string `json:"id" gorm:"column:id;type:uuid;primaryKey;"`
-	PlateNumber string `json:"plate_number" gorm:"column:plate_number;"`
+	ID              string `json:"id" gorm:"column:id;type:uuid;primaryKey;"`
+	PlateNumber     string `json:"plate_number" gorm:"column:plate_number;"`
+	NormalizedPlate string `json:"normalized_plate" gorm:"column:normalized_plate;index"`
 
 	IsEntry      bool             `json:"is_entry" gorm:"column:is_entry"`
 	EntryPointID *string          `json:"entry_point_id,omitempty" gorm:"column:entry_point_id;type:uuid"`
@@ -74,6 +102,8 @@ type GuestVehicleActivity struct {
 	EntryPoint   *AccessExitPoint `json:"entry_point,omitempty" gorm:"foreignKey:EntryPointID"`
 	ExitPoint    *AccessExitPoint `json:"exit_point,omitempty" gorm:"foreignKey:ExitPointID"`
 
+	ChainLink
+
 	Timestamp time.Time      `json:"timestamp" gorm:"column:timestamp;not null;default:CURRENT_TIMESTAMP"`
 	CreatedAt time.Time      `json:"createdAt" gorm:"column:created_at"`
 	DeletedAt gorm.DeletedAt `json:"deletedAt" gorm:"column:deleted_at"`
@@ -81,18 +111,20 @@ type GuestVehicleActivity struct {
 
 func (va *VehicleActivity) BeforeCreate(tx *gorm.DB) (err error) {
 	va.ID = utility.GenerateUUID()
+	va.NormalizedPlate = utility.NormalizePlateNumber(va.PlateNumber)
 	if va.Timestamp.IsZero() {
 		va.Timestamp = time.Now()
 	}
-	return
+	return AppendToChain(tx, ActivityChainVehicle, &va.ChainLink, va.ChainContent())
 }
 
 func (va *GuestVehicleActivity) BeforeCreate(tx *gorm.DB) (err error) {
 	va.ID = utility.GenerateUUID()
+	va.NormalizedPlate = utility.NormalizePlateNumber(va.PlateNumber)
 	if va.Timestamp.IsZero() {
 		va.Timestamp = time.Now()
 	}
-	return
+	return AppendToChain(tx, ActivityChainGuest, &va.ChainLink, va.ChainContent())
 }
 
 type LogVehicleActivityInput struct {
@@ -118,12 +150,35 @@ type VehicleIdentity struct {
 	PlateNumber  string `json:"plate_number"`
 	Status       string `json:"status"`
 	IsRegistered bool   `json:"is_registered"`
+	// MatchType is "exact", "fuzzy" or "none". Fuzzy matches that were not
+	// auto-accepted leave the vehicle fields empty and list the candidates.
+	MatchType          string             `json:"match_type"`
+	Score              float64            `json:"score,omitempty"`
+	VehicleID          string             `json:"vehicle_id,omitempty"`
+	MatchedPlateNumber string             `json:"matched_plate_number,omitempty"`
+	Candidates         []VehicleCandidate `json:"candidates,omitempty"`
+}
+
+// VehicleCandidate is a registered vehicle whose plate may be the one read.
+type VehicleCandidate struct {
+	VehicleID   string  `json:"vehicle_id"`
+	PlateNumber string  `json:"plate_number"`
+	Score       float64 `json:"score"`
+	MatchType   string  `json:"match_type"`
 }
 
-func (v *Vehicle) DeRegister(db *gorm.DB) error {
-	if err := db.Unscoped().Delete(&v).Error; err != nil {
+// Archive takes the vehicle out of service while keeping the row, so the
+// plate can be registered again and old activity logs still resolve.
+func (v *Vehicle) Archive(db *gorm.DB, archivedByID, reason string) error {
+	err := db.Model(v).Updates(map[string]any{
+		"archived_by_id": archivedByID,
+		"archive_reason": reason,
+	}).Error
+	if err != nil {
 		return err
 	}
 
-	return nil
+	v.ArchivedByID = &archivedByID
+	v.ArchiveReason = reason
+	return db.Delete(v).Error
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AttachmentFrontPhoto   = "front_photo"
+	AttachmentRearPhoto    = "rear_photo"
+	AttachmentRegistration = "registration"
+	AttachmentInsurance    = "insurance"
+)
+
+// AttachmentKinds maps each kind of attachment to the content types it
+// accepts. Photos must be images; papers may also be scanned to PDF.
+var AttachmentKinds = map[string][]string{
+	AttachmentFrontPhoto:   {"image/jpeg", "image/png"},
+	AttachmentRearPhoto:    {"image/jpeg", "image/png"},
+	AttachmentRegistration: {"image/jpeg", "image/png", "application/pdf"},
+	AttachmentInsurance:    {"image/jpeg", "image/png", "application/pdf"},
+}
+
+// VehicleAttachment is a photo or document uploaded for a vehicle. The file
+// itself lives in blob storage under StorageKey; URL and ThumbnailURL are
+// short-lived signed links filled in when the attachment is returned.
+type VehicleAttachment struct {
+	ID           string         `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	VehicleID    string         `gorm:"column:vehicle_id;type:uuid;not null;index" json:"vehicle_id"`
+	Kind         string         `gorm:"column:kind;not null" json:"kind"`
+	FileName     string         `gorm:"column:file_name" json:"file_name"`
+	ContentType  string         `gorm:"column:content_type;not null" json:"content_type"`
+	Size         int64          `gorm:"column:size" json:"size"`
+	Checksum     string         `gorm:"column:checksum" json:"checksum"`
+	StorageKey   string         `gorm:"column:storage_key;not null" json:"-"`
+	ThumbnailKey string         `gorm:"column:thumbnail_key" json:"-"`
+	UploadedByID string         `gorm:"column:uploaded_by_id;type:uuid" json:"uploaded_by_id"`
+	URL          string         `gorm:"-" json:"url,omitempty"`
+	ThumbnailURL string         `gorm:"-" json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"-"`
+}
+
+func (va *VehicleAttachment) BeforeCreate(tx *gorm.DB) (err error) {
+	if va.ID == "" {
+		va.ID = utility.GenerateUUID()
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	VehicleRoleOwner  = "owner"
+	VehicleRoleDriver = "driver"
+)
+
+const (
+	VehicleDriverPending  = "pending"
+	VehicleDriverActive   = "active"
+	VehicleDriverDeclined = "declined"
+	VehicleDriverRevoked  = "revoked"
+)
+
+// VehicleDriver authorizes a user to drive a vehicle. Every authorized user
+// is asked to confirm the vehicle's exits. The owner's record mirrors
+// Vehicle.UserID; drivers are invited by the owner and must accept.
+type VehicleDriver struct {
+	ID          string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	VehicleID   string     `gorm:"column:vehicle_id;type:uuid;not null;index" json:"vehicle_id"`
+	Vehicle     *Vehicle   `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+	UserID      string     `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Role        string     `gorm:"column:role;not null" json:"role"`
+	Status      string     `gorm:"column:status;not null;index" json:"status"`
+	InvitedByID *string    `gorm:"column:invited_by_id;type:uuid" json:"invited_by_id,omitempty"`
+	AcceptedAt  *time.Time `gorm:"column:accepted_at" json:"accepted_at,omitempty"`
+	RevokedAt   *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (vd *VehicleDriver) BeforeCreate(tx *gorm.DB) (err error) {
+	vd.ID = utility.GenerateUUID()
+	return
+}
+
+type InviteVehicleDriverInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
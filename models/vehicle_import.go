@@ -0,0 +1,29 @@
+package models
+
+const (
+	VehicleImportDryRun = "dry_run"
+	VehicleImportCommit = "commit"
+)
+
+// VehicleCSVHeader is the column layout of vehicle imports and exports, so an
+// export can be edited and imported again.
+var VehicleCSVHeader = []string{"owner_email", "plate_number", "model", "color", "type"}
+
+// VehicleImportResult reports what an import found and, outside of dry runs,
+// how many vehicles it registered.
+type VehicleImportResult struct {
+	Mode       string                  `json:"mode"`
+	TotalRows  int                     `json:"total_rows"`
+	ValidRows  int                     `json:"valid_rows"`
+	Imported   int                     `json:"imported"`
+	RowErrors  []VehicleImportRowError `json:"row_errors"`
+	VehicleIDs []string                `json:"vehicle_ids,omitempty"`
+}
+
+// VehicleImportRowError lists the problems with one CSV row. Row is the line
+// number in the file, the header being line 1.
+type VehicleImportRowError struct {
+	Row         int      `json:"row"`
+	PlateNumber string   `json:"plate_number,omitempty"`
+	Errors      []string `json:"errors"`
+}
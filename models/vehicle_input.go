@@ -4,7 +4,22 @@ type RegisterVehicleInput struct {
 	PlateNumber string `json:"plate_number" validate:"required"`
 	Model       string `json:"model" validate:"required"`
 	Color       string `json:"color" validate:"required"`
-	Type        string `json:"type" validate:"required,oneof=bus bike car"`
+	Type        string `json:"type" validate:"required,vehicle_type"`
+}
+
+type ReviewVehicleInput struct {
+	Approve bool   `json:"approve"`
+	Reason  string `json:"reason" validate:"max=500"`
+}
+
+type DeRegisterVehicleInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// RestoreVehicleInput brings an archived vehicle back. Giving the email of
+// another user registers the plate to them as a new vehicle instead.
+type RestoreVehicleInput struct {
+	Email string `json:"email" validate:"omitempty,email"`
 }
 
 type UpdateVehicleInput struct {
@@ -15,7 +30,7 @@ type UpdateVehicleInput struct {
 type LogVehicleInput struct {
 	PlateNumber  string `json:"plate_number" validate:"required"`
 	IsEntry      bool   `json:"is_entry"`
-	Type         string `json:"type" validate:"oneof=bus bike car"`
+	Type         string `json:"type" validate:"vehicle_type"`
 	EntryPointID string `json:"entry_point_id,omitempty"`
 	ExitPointID  string `json:"exit_point_id,omitempty"`
 }
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TransferStatusPendingAcceptance = "pending_acceptance"
+	TransferStatusPendingApproval   = "pending_approval"
+	TransferStatusCompleted         = "completed"
+	TransferStatusDeclined          = "declined"
+	TransferStatusCancelled         = "cancelled"
+	TransferStatusRejected          = "rejected"
+)
+
+// VehicleTransfer hands a vehicle from its owner to another user. The
+// recipient accepts it and, when the site requires it, security approves it.
+type VehicleTransfer struct {
+	ID               string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	VehicleID        string     `gorm:"column:vehicle_id;type:uuid;not null;index" json:"vehicle_id"`
+	Vehicle          *Vehicle   `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+	FromUserID       string     `gorm:"column:from_user_id;type:uuid;not null;index" json:"from_user_id"`
+	ToUserID         string     `gorm:"column:to_user_id;type:uuid;not null;index" json:"to_user_id"`
+	ToEmail          string     `gorm:"column:to_email;not null" json:"to_email"`
+	Status           string     `gorm:"column:status;not null;index" json:"status"`
+	RequiresApproval bool       `gorm:"column:requires_approval" json:"requires_approval"`
+	ExpiresAt        time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	AcceptedAt       *time.Time `gorm:"column:accepted_at" json:"accepted_at,omitempty"`
+	ReviewedByID     *string    `gorm:"column:reviewed_by_id;type:uuid" json:"reviewed_by_id,omitempty"`
+	ReviewReason     string     `gorm:"column:review_reason" json:"review_reason,omitempty"`
+	CompletedAt      *time.Time `gorm:"column:completed_at" json:"completed_at,omitempty"`
+	ClosedAt         *time.Time `gorm:"column:closed_at" json:"closed_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (vt *VehicleTransfer) BeforeCreate(tx *gorm.DB) (err error) {
+	vt.ID = utility.GenerateUUID()
+	return
+}
+
+// IsOpen reports whether the transfer is still waiting on someone.
+func (vt *VehicleTransfer) IsOpen() bool {
+	return vt.Status == TransferStatusPendingAcceptance || vt.Status == TransferStatusPendingApproval
+}
+
+// VehicleOwnership is one owner's period of ownership of a vehicle. The
+// current owner's record has no EndedAt.
+type VehicleOwnership struct {
+	ID          string     `gorm:"column:id;type:uuid;primaryKey;" json:"id"`
+	VehicleID   string     `gorm:"column:vehicle_id;type:uuid;not null;index" json:"vehicle_id"`
+	UserID      string     `gorm:"column:user_id;type:uuid;not null;index" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	PlateNumber string     `gorm:"column:plate_number" json:"plate_number"`
+	TransferID  *string    `gorm:"column:transfer_id;type:uuid" json:"transfer_id,omitempty"`
+	StartedAt   time.Time  `gorm:"column:started_at;not null" json:"started_at"`
+	EndedAt     *time.Time `gorm:"column:ended_at" json:"ended_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (vo *VehicleOwnership) BeforeCreate(tx *gorm.DB) (err error) {
+	vo.ID = utility.GenerateUUID()
+	return
+}
+
+type CreateVehicleTransferInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ReviewVehicleTransferInput struct {
+	Approve bool   `json:"approve"`
+	Reason  string `json:"reason" validate:"max=500"`
+}
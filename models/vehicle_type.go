@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	ParkingSizeSmall    = "small"
+	ParkingSizeStandard = "standard"
+	ParkingSizeLarge    = "large"
+)
+
+// VehicleType is an entry in the admin-managed catalog of vehicle types.
+// Vehicles and activity records store its Code. Types are deactivated rather
+// than deleted so old records keep a valid type.
+type VehicleType struct {
+	Code           string    `gorm:"column:code;primaryKey;type:varchar(20)" json:"code"`
+	Name           string    `gorm:"column:name;not null" json:"name"`
+	ParkingSize    string    `gorm:"column:parking_size;not null;default:'standard'" json:"parking_size"`
+	RequiresEscort bool      `gorm:"column:requires_escort" json:"requires_escort"`
+	Active         bool      `gorm:"column:active;not null;default:true" json:"active"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// DefaultVehicleTypes seeds the catalog on first start. The first three are
+// the types the application originally accepted.
+var DefaultVehicleTypes = []VehicleType{
+	{Code: "car", Name: "Car", ParkingSize: ParkingSizeStandard},
+	{Code: "bike", Name: "Motorcycle", ParkingSize: ParkingSizeSmall},
+	{Code: "bus", Name: "Bus", ParkingSize: ParkingSizeLarge},
+	{Code: "keke", Name: "Tricycle (keke)", ParkingSize: ParkingSizeSmall},
+	{Code: "van", Name: "Van", ParkingSize: ParkingSizeStandard},
+	{Code: "truck", Name: "Truck", ParkingSize: ParkingSizeLarge, RequiresEscort: true},
+}
+
+type CreateVehicleTypeInput struct {
+	Code           string `json:"code" validate:"required,max=20,lowercase,alphanum"`
+	Name           string `json:"name" validate:"required,max=100"`
+	ParkingSize    string `json:"parking_size" validate:"omitempty,oneof=small standard large"`
+	RequiresEscort bool   `json:"requires_escort"`
+}
+
+type UpdateVehicleTypeInput struct {
+	Name           *string `json:"name" validate:"omitempty,max=100"`
+	ParkingSize    *string `json:"parking_size" validate:"omitempty,oneof=small standard large"`
+	RequiresEscort *bool   `json:"requires_escort"`
+	Active         *bool   `json:"active"`
+}
@@ -0,0 +1,27 @@
+package plates
+
+import "regexp"
+
+func init() {
+	Register(benin{})
+}
+
+var beninFormats = []format{
+	// CD 123 RB
+	{pattern: regexp.MustCompile(`^CD([0-9]{1,4})RB$`), category: CategoryDiplomatic, serial: []int{1}},
+	// AB 1234 RB: series letters and number, then RB for République du Bénin
+	{pattern: regexp.MustCompile(`^([A-Z]{2})([0-9]{4})RB$`), category: CategoryStandard, serial: []int{1, 2}},
+}
+
+type benin struct{}
+
+func (benin) Country() string { return "BJ" }
+
+func (benin) Parse(normalized string) (*Plate, bool) {
+	for _, f := range beninFormats {
+		if plate, ok := f.parse("BJ", normalized); ok {
+			return plate, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,65 @@
+package plates
+
+import "regexp"
+
+func init() {
+	Register(ghana{})
+}
+
+var ghanaianRegions = map[string]string{
+	"AS": "Ashanti",
+	"AH": "Ahafo",
+	"BA": "Bono",
+	"BT": "Bono East",
+	"CR": "Central",
+	"ER": "Eastern",
+	"GC": "Greater Accra",
+	"GE": "Greater Accra",
+	"GN": "Greater Accra",
+	"GR": "Greater Accra",
+	"GS": "Greater Accra",
+	"GT": "Greater Accra",
+	"GW": "Greater Accra",
+	"GX": "Greater Accra",
+	"NE": "North East",
+	"NR": "Northern",
+	"OT": "Oti",
+	"SV": "Savannah",
+	"UE": "Upper East",
+	"UW": "Upper West",
+	"VR": "Volta",
+	"WN": "Western North",
+	"WR": "Western",
+}
+
+var ghanaianFormats = []format{
+	// CD 1234, with CC and IO for consular and international organization staff
+	{pattern: regexp.MustCompile(`^(CD|CC|IO)([0-9]{1,4})$`), category: CategoryDiplomatic, region: 1, serial: []int{2}},
+	// GV 123-20 for government and GP for police vehicles
+	{pattern: regexp.MustCompile(`^(GV|GP)([0-9]{1,4})([0-9]{2}|[A-Z])?$`), category: CategoryGovernment, region: 1, serial: []int{2, 3}},
+	// GR 1234-20: region, number, then the year of registration or a series letter
+	{pattern: regexp.MustCompile(`^([A-Z]{2})([0-9]{1,4})([0-9]{2}|[A-Z])$`), category: CategoryStandard, region: 1, serial: []int{2, 3}},
+}
+
+type ghana struct{}
+
+func (ghana) Country() string { return "GH" }
+
+func (ghana) Parse(normalized string) (*Plate, bool) {
+	for _, f := range ghanaianFormats {
+		plate, ok := f.parse("GH", normalized)
+		if !ok {
+			continue
+		}
+
+		if f.category == CategoryStandard {
+			name, known := ghanaianRegions[plate.Region]
+			if !known {
+				continue
+			}
+			plate.RegionName = name
+		}
+		return plate, true
+	}
+	return nil, false
+}
@@ -0,0 +1,90 @@
+package plates
+
+import "regexp"
+
+func init() {
+	Register(nigeria{})
+}
+
+var nigerianStates = map[string]string{
+	"AB": "Abia",
+	"AD": "Adamawa",
+	"AK": "Akwa Ibom",
+	"AN": "Anambra",
+	"BA": "Bauchi",
+	"BY": "Bayelsa",
+	"BE": "Benue",
+	"BO": "Borno",
+	"CR": "Cross River",
+	"DE": "Delta",
+	"EB": "Ebonyi",
+	"ED": "Edo",
+	"EK": "Ekiti",
+	"EN": "Enugu",
+	"GO": "Gombe",
+	"IM": "Imo",
+	"JI": "Jigawa",
+	"KD": "Kaduna",
+	"KN": "Kano",
+	"KT": "Katsina",
+	"KE": "Kebbi",
+	"KO": "Kogi",
+	"KW": "Kwara",
+	"LA": "Lagos",
+	"NA": "Nasarawa",
+	"NI": "Niger",
+	"OG": "Ogun",
+	"ON": "Ondo",
+	"OS": "Osun",
+	"OY": "Oyo",
+	"PL": "Plateau",
+	"RI": "Rivers",
+	"SO": "Sokoto",
+	"TA": "Taraba",
+	"YO": "Yobe",
+	"ZA": "Zamfara",
+	"FC": "Federal Capital Territory (Abuja)",
+}
+
+var nigerianServices = map[string]string{
+	"NA": "Nigerian Army",
+	"AF": "Nigerian Air Force",
+	"NN": "Nigerian Navy",
+}
+
+var nigerianFormats = []format{
+	// CD 123 A
+	{pattern: regexp.MustCompile(`^CD([0-9]{3}[A-Z])$`), category: CategoryDiplomatic, serial: []int{1}},
+	// NA 1234 A, with AF and NN for the other services
+	{pattern: regexp.MustCompile(`^(NA|AF|NN)([0-9]{3,4}[A-Z]?)$`), category: CategoryMilitary, region: 1, serial: []int{2}},
+	// ABC 123 DE, issued since 2011: local government code, number, series
+	{pattern: regexp.MustCompile(`^([A-Z]{3})([0-9]{3})([A-Z]{2})$`), category: CategoryStandard, region: 1, serial: []int{2, 3}},
+	// LA 123 ABC, the older layout led by the state code
+	{pattern: regexp.MustCompile(`^([A-Z]{2})([0-9]{3})([A-Z]{3})$`), category: CategoryLegacy, region: 1, serial: []int{2, 3}},
+}
+
+type nigeria struct{}
+
+func (nigeria) Country() string { return "NG" }
+
+func (nigeria) Parse(normalized string) (*Plate, bool) {
+	for _, f := range nigerianFormats {
+		plate, ok := f.parse("NG", normalized)
+		if !ok {
+			continue
+		}
+
+		switch f.category {
+		case CategoryLegacy:
+			name, known := nigerianStates[plate.Region]
+			if !known {
+				continue
+			}
+			plate.RegionName = name
+		case CategoryMilitary:
+			plate.RegionName = nigerianServices[plate.Region]
+		}
+		return plate, true
+	}
+	return nil, false
+}
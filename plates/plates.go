@@ -0,0 +1,123 @@
+package plates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"survielx-backend/utility"
+	"sync"
+)
+
+const (
+	CategoryStandard   = "standard"
+	CategoryLegacy     = "legacy"
+	CategoryGovernment = "government"
+	CategoryDiplomatic = "diplomatic"
+	CategoryMilitary   = "military"
+)
+
+// Plate is a plate number broken into its parts. Region is the state, region
+// or issuing service the plate belongs to and Serial is the rest of it.
+type Plate struct {
+	Country    string `json:"country"`
+	Region     string `json:"region,omitempty"`
+	RegionName string `json:"region_name,omitempty"`
+	Serial     string `json:"serial"`
+	Category   string `json:"category"`
+	Normalized string `json:"normalized"`
+}
+
+// PlateValidator recognizes the plate formats issued by one country.
+// Implementations must be safe for concurrent use.
+type PlateValidator interface {
+	// Country is the ISO 3166-1 alpha-2 code of the issuing country.
+	Country() string
+	// Parse reports whether a normalized plate number is valid in the
+	// country and, if so, its parts.
+	Parse(normalized string) (*Plate, bool)
+}
+
+var ErrInvalidPlate = errors.New("not a valid plate number")
+
+var (
+	mu         sync.RWMutex
+	validators = map[string]PlateValidator{}
+)
+
+// Register makes a validator available under its country code, replacing any
+// validator registered for the same country.
+func Register(v PlateValidator) {
+	mu.Lock()
+	defer mu.Unlock()
+	validators[strings.ToUpper(v.Country())] = v
+}
+
+// Lookup returns the validator registered for a country.
+func Lookup(country string) (PlateValidator, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	v, ok := validators[strings.ToUpper(country)]
+	return v, ok
+}
+
+// Countries lists the registered country codes.
+func Countries() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	countries := make([]string, 0, len(validators))
+	for country := range validators {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// Parse normalizes a plate number and tries each of the given countries in
+// order, returning the parts from the first one that accepts it.
+func Parse(plateNumber string, countries []string) (*Plate, error) {
+	normalized := utility.NormalizePlateNumber(plateNumber)
+	if normalized == "" {
+		return nil, ErrInvalidPlate
+	}
+
+	for _, country := range countries {
+		v, ok := Lookup(country)
+		if !ok {
+			return nil, fmt.Errorf("no plate validator for country %s", country)
+		}
+		if plate, ok := v.Parse(normalized); ok {
+			plate.Normalized = normalized
+			return plate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for %s", ErrInvalidPlate, strings.Join(countries, ", "))
+}
+
+// format is one plate layout. The pattern's submatches are picked out by
+// index; a zero index leaves that part empty.
+type format struct {
+	pattern  *regexp.Regexp
+	category string
+	region   int
+	serial   []int
+}
+
+func (f format) parse(country, normalized string) (*Plate, bool) {
+	m := f.pattern.FindStringSubmatch(normalized)
+	if m == nil {
+		return nil, false
+	}
+
+	plate := &Plate{Country: country, Category: f.category}
+	if f.region > 0 {
+		plate.Region = m[f.region]
+	}
+	for _, i := range f.serial {
+		plate.Serial += m[i]
+	}
+	return plate, true
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"survielx-backend/controllers"
 	"survielx-backend/middleware"
+	"survielx-backend/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,11 +13,10 @@ func AccessExitPointRoutes(r *gin.Engine, api_version string) {
 	access := r.Group(fmt.Sprintf("%v/access-exit-points", api_version))
 	access.Use(middleware.AuthMiddleware())
 	{
-		access.POST("/", controllers.CreateAccessExitPoint, middleware.SecurityMiddleware())
+		access.POST("/", middleware.RequirePermission(models.PermissionGatesManage), controllers.CreateAccessExitPoint)
 		access.GET("/", controllers.GetAccessExitPoints)
 		access.GET("/:id", controllers.GetAccessExitPoint)
-		access.DELETE("/:id", controllers.DeleteAccessExitPoint, middleware.SecurityMiddleware())
-		access.PUT("/:id", middleware.SecurityMiddleware(), controllers.UpdateAccessExitPoint)
+		access.DELETE("/:id", middleware.RequirePermission(models.PermissionGatesManage), controllers.DeleteAccessExitPoint)
+		access.PUT("/:id", middleware.RequirePermission(models.PermissionGatesManage), controllers.UpdateAccessExitPoint)
 	}
 }
-																
\ No newline at end of file
@@ -0,0 +1,63 @@
+package routers
+
+import (
+	"fmt"
+	"survielx-backend/controllers"
+	"survielx-backend/middleware"
+	"survielx-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminRoutes(r *gin.Engine, api_version string) {
+	adminRoutes := r.Group(fmt.Sprintf("%v/admin", api_version))
+	adminRoutes.Use(middleware.AuthMiddleware())
+	{
+		manageInvitations := middleware.RequirePermission(models.PermissionInvitationsManage)
+		adminRoutes.POST("/invitations", manageInvitations, controllers.CreateInvitation)
+		adminRoutes.GET("/invitations", manageInvitations, controllers.GetInvitations)
+		adminRoutes.DELETE("/invitations/:id", manageInvitations, controllers.RevokeInvitation)
+
+		readUsers := middleware.RequirePermission(models.PermissionUsersRead)
+		adminRoutes.GET("/users", readUsers, controllers.GetUsers)
+		adminRoutes.GET("/users/:id", readUsers, controllers.GetUserDetails)
+
+		manageUsers := middleware.RequirePermission(models.PermissionUsersManage)
+		adminRoutes.PUT("/users/:id/role", manageUsers, controllers.UpdateUserRole)
+		adminRoutes.POST("/users/:id/suspend", manageUsers, controllers.SuspendUser)
+		adminRoutes.POST("/users/:id/reactivate", manageUsers, controllers.ReactivateUser)
+		adminRoutes.POST("/users/:id/reset-password", manageUsers, controllers.ResetUserPassword)
+		adminRoutes.POST("/users/:id/unlock", manageUsers, controllers.UnlockAccount)
+		adminRoutes.GET("/lockouts", manageUsers, controllers.GetAccountLockouts)
+
+		manageRoles := middleware.RequirePermission(models.PermissionRolesManage)
+		adminRoutes.GET("/permissions", manageRoles, controllers.GetPermissions)
+		adminRoutes.GET("/roles", manageRoles, controllers.GetRolePermissions)
+		adminRoutes.PUT("/roles/:role/permissions", manageRoles, controllers.UpdateRolePermissions)
+
+		readAudit := middleware.RequirePermission(models.PermissionAuditRead)
+		adminRoutes.GET("/audit", readAudit, controllers.GetAuditEvents)
+		adminRoutes.GET("/activity-chain/verify", readAudit, controllers.VerifyActivityChains)
+
+		adminRoutes.GET("/plate-conflicts", middleware.RequirePermission(models.PermissionVehiclesReadAny), controllers.GetPlateConflicts)
+
+		restoreVehicles := middleware.RequirePermission(models.PermissionVehiclesRestore)
+		adminRoutes.GET("/vehicles/archived", restoreVehicles, controllers.GetArchivedVehicles)
+		adminRoutes.POST("/vehicles/:vehicle_id/restore", restoreVehicles, controllers.RestoreVehicle)
+
+		bulkVehicles := middleware.RequirePermission(models.PermissionVehiclesBulk)
+		adminRoutes.POST("/vehicles/import", bulkVehicles, controllers.ImportVehicles)
+		adminRoutes.GET("/vehicles/export", bulkVehicles, controllers.ExportVehicles)
+
+		manageVehicleTypes := middleware.RequirePermission(models.PermissionVehicleTypesManage)
+		adminRoutes.GET("/vehicle-types", manageVehicleTypes, controllers.GetAllVehicleTypes)
+		adminRoutes.POST("/vehicle-types", manageVehicleTypes, controllers.CreateVehicleType)
+		adminRoutes.PATCH("/vehicle-types/:code", manageVehicleTypes, controllers.UpdateVehicleType)
+
+		manageMachines := middleware.RequirePermission(models.PermissionMachinesManage)
+		adminRoutes.POST("/machine-clients", manageMachines, controllers.CreateMachineClient)
+		adminRoutes.GET("/machine-clients", manageMachines, controllers.GetMachineClients)
+		adminRoutes.POST("/machine-clients/:id/rotate", manageMachines, controllers.RotateMachineClientSecret)
+		adminRoutes.DELETE("/machine-clients/:id", manageMachines, controllers.RevokeMachineClient)
+	}
+}
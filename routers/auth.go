@@ -3,6 +3,7 @@ package routers
 import (
 	"fmt"
 	"survielx-backend/controllers"
+	"survielx-backend/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,5 +13,21 @@ func AuthRoutes(r *gin.Engine, api_version string) {
 	{
 		authRoutes.POST("/register", controllers.Register)
 		authRoutes.POST("/login", controllers.Login)
+		authRoutes.POST("/login/2fa", controllers.LoginTwoFactor)
+		authRoutes.POST("/refresh", controllers.RefreshToken)
+		authRoutes.POST("/logout", middleware.AuthMiddleware(), controllers.Logout)
+		authRoutes.POST("/logout-all", middleware.AuthMiddleware(), controllers.LogoutAll)
+		authRoutes.POST("/change-password", middleware.AuthMiddleware(), controllers.ChangePassword)
+		authRoutes.POST("/forgot-password", controllers.ForgotPassword)
+		authRoutes.POST("/reset-password", controllers.ResetPassword)
+		authRoutes.POST("/verify-email", controllers.VerifyEmail)
+		authRoutes.POST("/resend-verification", middleware.AuthMiddleware(), controllers.ResendVerificationEmail)
+		authRoutes.POST("/invitations/accept", controllers.AcceptInvitation)
+
+		// OpenID Connect single sign-on
+		authRoutes.GET("/oidc/providers", controllers.GetOIDCProviders)
+		authRoutes.GET("/oidc/:provider/authorize", controllers.StartOIDCLogin)
+		authRoutes.GET("/oidc/:provider/callback", controllers.OIDCCallback)
+		authRoutes.POST("/oidc/:provider/callback", controllers.OIDCCallback)
 	}
 }
@@ -15,6 +15,16 @@ func UserProfileRoutes(r *gin.Engine, api_version string) {
 		// Current user profile operations
 		profileRoutes.PUT("/", controllers.UpdateUserProfile)
 		profileRoutes.GET("/", controllers.GetUserProfile)
+
+		// Two-factor authentication enrollment
+		profileRoutes.POST("/2fa/setup", controllers.SetupTwoFactor)
+		profileRoutes.POST("/2fa/enable", controllers.EnableTwoFactor)
+		profileRoutes.POST("/2fa/disable", controllers.DisableTwoFactor)
+		profileRoutes.POST("/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+
+		// Signed-in devices
+		profileRoutes.GET("/sessions", controllers.GetUserSessions)
+		profileRoutes.DELETE("/sessions/:session_id", controllers.RevokeSession)
 	}
 
 }
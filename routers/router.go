@@ -1,7 +1,10 @@
 package routers
 
 import (
+	"log"
 	"net/http"
+	"os"
+	"strings"
 	"survielx-backend/controllers"
 	"survielx-backend/middleware"
 
@@ -12,6 +15,13 @@ import (
 
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
+	// the client IP keys login lockouts and is recorded on sessions and audit
+	// events, so forwarding headers are only believed from known proxies
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Printf("Invalid TRUSTED_PROXIES, trusting no proxies: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+	r.Use(middleware.RequestIDMiddleware())
 	r.Use(middleware.CORSMiddleware())
 
 	ApiVersion := "api/v1"
@@ -21,9 +31,13 @@ func SetupRouter() *gin.Engine {
 	VehicleActivityRoutes(r, ApiVersion)
 	AccessExitPointRoutes(r, ApiVersion)
 	UserProfileRoutes(r, ApiVersion)
+	AdminRoutes(r, ApiVersion)
 	HealthRoutes(r, ApiVersion)
 
 	r.GET("/ws", controllers.WSHandler)
+	r.GET("/.well-known/jwks.json", controllers.GetJWKS)
+	// signed links to locally stored blobs; the signature is the credential
+	r.GET("/blobs/*key", controllers.ServeBlob)
 
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -51,3 +65,15 @@ func SetupRouter() *gin.Engine {
 
 	return r
 }
+
+// trustedProxies reads the comma separated IPs and CIDRs in TRUSTED_PROXIES.
+// Without it no proxy is trusted and the client IP is the connection's peer.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
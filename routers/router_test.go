@@ -0,0 +1,46 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The client IP keys the per-IP login lockout, so a forged X-Forwarded-For
+// must not change it unless the request came through a trusted proxy.
+func TestClientIPIgnoresUntrustedForwarding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		proxies string
+		peer    string
+		want    string
+	}{
+		{name: "no trusted proxies", peer: "203.0.113.7:4000", want: "203.0.113.7"},
+		{name: "peer is not a trusted proxy", proxies: "10.0.0.0/8", peer: "203.0.113.7:4000", want: "203.0.113.7"},
+		{name: "peer is a trusted proxy", proxies: "10.0.0.0/8, 192.0.2.1", peer: "10.1.2.3:4000", want: "198.51.100.9"},
+		{name: "invalid setting trusts no proxy", proxies: "not-an-ip", peer: "10.1.2.3:4000", want: "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.proxies)
+
+			r := SetupRouter()
+			r.GET("/client-ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/client-ip", nil)
+			req.RemoteAddr = tt.peer
+			req.Header.Set("X-Forwarded-For", "198.51.100.9")
+			req.Header.Set("X-Real-IP", "198.51.100.9")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,14 +4,15 @@ import (
 	"fmt"
 	"survielx-backend/controllers"
 	"survielx-backend/middleware"
+	"survielx-backend/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func UsersRoutes(r *gin.Engine, api_version string) {
 	authorized := r.Group(fmt.Sprintf("%v", api_version))
-	authorized.Use(middleware.AuthMiddleware(), middleware.SecurityMiddleware())
+	authorized.Use(middleware.AuthMiddleware())
 	{
-		authorized.GET("/users", controllers.GetUsers)
+		authorized.GET("/users", middleware.RequirePermission(models.PermissionUsersRead), controllers.GetUsers)
 	}
 }
@@ -7,37 +7,66 @@ import (
 
 	"survielx-backend/controllers"
 	"survielx-backend/middleware"
+	"survielx-backend/models"
 )
 
 func VehicleActivityRoutes(r *gin.Engine, api_version string) {
 
 	activityRoutes := r.Group(fmt.Sprintf("%v/vehicles", api_version), middleware.AuthMiddleware())
 	{
-		activityRoutes.POST("/register", controllers.RegisterVehicle)
+		activityRoutes.POST("/register", middleware.VerifiedEmailMiddleware(), controllers.RegisterVehicle)
 		activityRoutes.PATCH("/:vehicle_id", controllers.UpdateVehicle)
 		activityRoutes.DELETE("/:vehicle_id/deregister", controllers.DeRegisterVehicle)
 		activityRoutes.GET("/fetch_vehicles", controllers.GetUserVehicles)
+		activityRoutes.GET("/types", controllers.GetVehicleTypes)
 		activityRoutes.GET("/activities", controllers.GetVehiclesActivities)
 		activityRoutes.GET("/pending", controllers.GetPendingVehicles)
-		activityRoutes.PUT("/pending/:pending_id", controllers.GetPendingVehicles)
+		activityRoutes.PUT("/pending/:pending_id", controllers.UpdatePendingVehicle)
 		activityRoutes.GET("/:vehicle_id/activities", controllers.GetVehicleActivities)
+		activityRoutes.GET("/:vehicle_id/ownership-history", controllers.GetVehicleOwnershipHistory)
+		activityRoutes.POST("/:vehicle_id/transfers", controllers.InitiateVehicleTransfer)
+		activityRoutes.GET("/transfers", controllers.GetUserVehicleTransfers)
+		activityRoutes.POST("/transfers/:transfer_id/accept", middleware.VerifiedEmailMiddleware(), controllers.AcceptVehicleTransfer)
+		activityRoutes.POST("/transfers/:transfer_id/decline", controllers.DeclineVehicleTransfer)
+		activityRoutes.DELETE("/transfers/:transfer_id", controllers.CancelVehicleTransfer)
+		activityRoutes.GET("/shared", controllers.GetSharedVehicles)
+		activityRoutes.GET("/:vehicle_id/drivers", controllers.GetVehicleDrivers)
+		activityRoutes.POST("/:vehicle_id/drivers", controllers.InviteVehicleDriver)
+		activityRoutes.POST("/drivers/:driver_id/accept", middleware.VerifiedEmailMiddleware(), controllers.AcceptVehicleDriver)
+		activityRoutes.POST("/drivers/:driver_id/decline", controllers.DeclineVehicleDriver)
+		activityRoutes.DELETE("/drivers/:driver_id", controllers.RevokeVehicleDriver)
+		activityRoutes.POST("/:vehicle_id/attachments", controllers.UploadVehicleAttachment)
+		activityRoutes.GET("/:vehicle_id/attachments", controllers.GetVehicleAttachments)
+		activityRoutes.DELETE("/:vehicle_id/attachments/:attachment_id", controllers.DeleteVehicleAttachment)
 	}
 
-	securityRoutes := r.Group(fmt.Sprintf("%v/security", api_version), middleware.AuthMiddleware(), middleware.SecurityMiddleware())
+	securityRoutes := r.Group(fmt.Sprintf("%v/security", api_version), middleware.AuthMiddleware())
 	{
-		securityRoutes.POST("/log-vehicle", controllers.LogVehicleActivity)
-		securityRoutes.POST("/log-guest-vehicle", controllers.LogGuestVehicleActivity)
-		securityRoutes.GET("/vehicle/:vehicle_id/activities", controllers.GetVehicleActivities)
-		securityRoutes.GET("/activities/:plateNumber", controllers.GetGuestVehicleActivitiesByPlateNumber)
-		securityRoutes.GET("/registered-logs", controllers.FetchRegisteredVehiclesLogs)
-		securityRoutes.GET("/guest-logs", controllers.FetchGuestVehiclesLogs)
-		securityRoutes.GET("/:vehicle_id/owner-profile", controllers.GetVehicleOwnerProfile)
-		securityRoutes.GET("/activity-report", controllers.GenerateActivityReport)
+		canLog := middleware.RequirePermission(models.PermissionVehiclesLog)
+		canRead := middleware.RequirePermission(models.PermissionVehiclesReadAny)
+		canApproveTransfers := middleware.RequirePermission(models.PermissionTransfersApprove)
+		canReview := middleware.RequirePermission(models.PermissionVehiclesReview)
+
+		securityRoutes.POST("/log-vehicle", canLog, controllers.LogVehicleActivity)
+		securityRoutes.POST("/log-guest-vehicle", canLog, controllers.LogGuestVehicleActivity)
+		securityRoutes.GET("/vehicle/:vehicle_id/activities", canRead, controllers.GetVehicleActivities)
+		securityRoutes.GET("/activities/:plateNumber", canRead, controllers.GetGuestVehicleActivitiesByPlateNumber)
+		securityRoutes.GET("/registered-logs", canRead, controllers.FetchRegisteredVehiclesLogs)
+		securityRoutes.GET("/guest-logs", canRead, controllers.FetchGuestVehiclesLogs)
+		securityRoutes.GET("/:vehicle_id/owner-profile", canRead, controllers.GetVehicleOwnerProfile)
+		securityRoutes.GET("/vehicle/:vehicle_id/ownership-history", canRead, controllers.GetAnyVehicleOwnershipHistory)
+		securityRoutes.GET("/vehicle/:vehicle_id/attachments", canRead, controllers.GetAnyVehicleAttachments)
+		securityRoutes.GET("/vehicle-reviews", canReview, controllers.GetVehicleReviewQueue)
+		securityRoutes.POST("/vehicle/:vehicle_id/review", canReview, controllers.ReviewVehicle)
+		securityRoutes.GET("/vehicle-transfers", canApproveTransfers, controllers.GetVehicleTransfers)
+		securityRoutes.POST("/vehicle-transfers/:transfer_id/review", canApproveTransfers, controllers.ReviewVehicleTransfer)
+		securityRoutes.GET("/activity-report", middleware.RequirePermission(models.PermissionReportsExport), controllers.GenerateActivityReport)
 	}
 
-	unauthRoutes := r.Group(fmt.Sprintf("%v/vehicles", api_version))
+	// the model backend calls these without user context, signing each request with its machine key
+	machineRoutes := r.Group(fmt.Sprintf("%v/vehicles", api_version))
 	{
-		unauthRoutes.GET("/identify/:plateNumber", controllers.IdentifyVehicle)
-		unauthRoutes.POST("/sys-log-vehicle", controllers.SystemLogVehicleActivity) //the model backend logs vehicle activity without user context
+		machineRoutes.GET("/identify/:plateNumber", middleware.MachineAuthMiddleware(models.MachineScopeVehiclesIdentify), controllers.IdentifyVehicle)
+		machineRoutes.POST("/sys-log-vehicle", middleware.MachineAuthMiddleware(models.MachineScopeVehiclesLog), controllers.SystemLogVehicleActivity)
 	}
 }
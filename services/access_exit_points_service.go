@@ -6,9 +6,13 @@ import (
 	"gorm.io/gorm"
 )
 
-func CreateAccessExitPoint(db *gorm.DB, point *models.AccessExitPoint) error {
-	result := db.Create(point)
-	return result.Error
+func CreateAccessExitPoint(db *gorm.DB, actor models.AuditActor, point *models.AccessExitPoint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(point).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditGateCreated, AuditTargetGate, point.ID, nil, point)
+	})
 }
 
 func GetAccessExitPoints(db *gorm.DB, points *[]models.AccessExitPoint) error {
@@ -21,12 +25,30 @@ func GetAccessExitPoint(db *gorm.DB, id string, point *models.AccessExitPoint) e
 	return result.Error
 }
 
-func UpdateAccessExitPoint(db *gorm.DB, point *models.AccessExitPoint) error {
-	result := db.Save(point)
-	return result.Error
+func UpdateAccessExitPoint(db *gorm.DB, actor models.AuditActor, point *models.AccessExitPoint) error {
+	var before models.AccessExitPoint
+	if err := db.First(&before, "id = ?", point.ID).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(point).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditGateUpdated, AuditTargetGate, point.ID, before, point)
+	})
 }
 
-func DeleteAccessExitPoint(db *gorm.DB, id string) error {
-	result := db.Delete(&models.AccessExitPoint{}, "id = ?", id)
-	return result.Error
+func DeleteAccessExitPoint(db *gorm.DB, actor models.AuditActor, id string) error {
+	var point models.AccessExitPoint
+	if err := db.First(&point, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.AccessExitPoint{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditGateDeleted, AuditTargetGate, id, point, nil)
+	})
 }
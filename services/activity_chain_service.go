@@ -0,0 +1,413 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const chainVerifyBatchSize = 500
+
+// chainEntry is an activity record as seen by the chain verifier.
+type chainEntry struct {
+	id      string
+	link    models.ChainLink
+	content any
+	deleted bool
+}
+
+type checkpointClaims struct {
+	Chain    string `json:"chain"`
+	Sequence int64  `json:"seq"`
+	Hash     string `json:"hash"`
+	jwt.RegisteredClaims
+}
+
+var errChainRecordLinked = errors.New("record is already linked")
+
+// BackfillActivityChains links activity records created before hashing was
+// introduced, oldest first. Soft-deleted records are left out.
+func BackfillActivityChains(db *gorm.DB) error {
+	for _, chain := range models.ActivityChains {
+		linked := 0
+		for {
+			entries, err := loadUnlinkedEntries(db, chain)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				break
+			}
+
+			for _, entry := range entries {
+				err := db.Transaction(func(tx *gorm.DB) error {
+					var link models.ChainLink
+					if err := models.AppendToChain(tx, chain, &link, entry.content); err != nil {
+						return err
+					}
+					// another instance may have linked it while we waited for the head
+					result := tx.Table(chain).
+						Where("id = ? AND (hash IS NULL OR hash = '')", entry.id).
+						UpdateColumns(map[string]any{
+							"chain_sequence": link.ChainSequence,
+							"prev_hash":      link.PrevHash,
+							"hash":           link.Hash,
+						})
+					if result.Error != nil {
+						return result.Error
+					}
+					if result.RowsAffected == 0 {
+						return errChainRecordLinked
+					}
+					return nil
+				})
+				if errors.Is(err, errChainRecordLinked) {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("failed to link %s record %s: %v", chain, entry.id, err)
+				}
+				linked++
+			}
+		}
+		if linked > 0 {
+			log.Printf("Linked %d existing %s records into the hash chain", linked, chain)
+		}
+	}
+	return nil
+}
+
+func loadUnlinkedEntries(db *gorm.DB, chain string) ([]chainEntry, error) {
+	unlinked := func(d *gorm.DB) *gorm.DB {
+		return d.Where("hash IS NULL OR hash = ''").Order("timestamp asc, created_at asc, id asc").Limit(chainVerifyBatchSize)
+	}
+
+	var entries []chainEntry
+	switch chain {
+	case models.ActivityChainVehicle:
+		var records []models.VehicleActivity
+		if err := db.Scopes(unlinked).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for i := range records {
+			entries = append(entries, chainEntry{id: records[i].ID, content: records[i].ChainContent()})
+		}
+	case models.ActivityChainGuest:
+		var records []models.GuestVehicleActivity
+		if err := db.Scopes(unlinked).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for i := range records {
+			entries = append(entries, chainEntry{id: records[i].ID, content: records[i].ChainContent()})
+		}
+	}
+	return entries, nil
+}
+
+// loadChainEntries returns linked records, deleted ones included, with
+// sequence numbers from..to in chain order.
+func loadChainEntries(db *gorm.DB, chain string, from, to int64, limit int) ([]chainEntry, error) {
+	inRange := func(d *gorm.DB) *gorm.DB {
+		return d.Unscoped().
+			Where("chain_sequence BETWEEN ? AND ?", from, to).
+			Order("chain_sequence asc").
+			Limit(limit)
+	}
+
+	var entries []chainEntry
+	switch chain {
+	case models.ActivityChainVehicle:
+		var records []models.VehicleActivity
+		if err := db.Scopes(inRange).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for i := range records {
+			entries = append(entries, chainEntry{
+				id:      records[i].ID,
+				link:    records[i].ChainLink,
+				content: records[i].ChainContent(),
+				deleted: records[i].DeletedAt.Valid,
+			})
+		}
+	case models.ActivityChainGuest:
+		var records []models.GuestVehicleActivity
+		if err := db.Scopes(inRange).Find(&records).Error; err != nil {
+			return nil, err
+		}
+		for i := range records {
+			entries = append(entries, chainEntry{
+				id:      records[i].ID,
+				link:    records[i].ChainLink,
+				content: records[i].ChainContent(),
+				deleted: records[i].DeletedAt.Valid,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// VerifyActivityChains checks the chains of the records logged between from
+// and to. Either bound may be nil. An empty chain name checks every chain.
+func VerifyActivityChains(db *gorm.DB, chain string, from, to *time.Time) ([]models.ChainVerificationReport, int, error) {
+	chains := models.ActivityChains
+	if chain != "" {
+		if !slices.Contains(models.ActivityChains, chain) {
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown chain %s", chain)
+		}
+		chains = []string{chain}
+	}
+
+	reports := make([]models.ChainVerificationReport, 0, len(chains))
+	for _, c := range chains {
+		report, err := verifyActivityChain(db, c, from, to)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to verify %s: %v", c, err)
+		}
+		reports = append(reports, *report)
+	}
+	return reports, http.StatusOK, nil
+}
+
+func verifyActivityChain(db *gorm.DB, chain string, from, to *time.Time) (*models.ChainVerificationReport, error) {
+	report := &models.ChainVerificationReport{Chain: chain, From: from, To: to, Valid: true}
+
+	var bounds struct {
+		First *int64
+		Last  *int64
+	}
+	query := db.Table(chain).Select("MIN(chain_sequence) AS first, MAX(chain_sequence) AS last").Where("chain_sequence > 0")
+	if from != nil {
+		query = query.Where("timestamp >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("timestamp <= ?", *to)
+	}
+	if err := query.Scan(&bounds).Error; err != nil {
+		return nil, err
+	}
+
+	var head models.ActivityChainHead
+	if err := db.Where("chain = ?", chain).Limit(1).Find(&head).Error; err != nil {
+		return nil, err
+	}
+
+	if bounds.First == nil {
+		// with an open end, a head past every record means the tail was removed
+		if to == nil && from == nil && head.Sequence > 0 {
+			report.Valid = false
+			report.FirstBreak = &models.ChainBreak{Sequence: 1, Reason: "records up to the chain head are missing"}
+		}
+		return report, nil
+	}
+	first, last := *bounds.First, *bounds.Last
+	report.FirstSequence, report.LastSequence = first, last
+
+	var checkpoints []models.ActivityCheckpoint
+	if err := db.Where("chain = ? AND sequence BETWEEN ? AND ?", chain, first, last).Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+	checkpointsAt := make(map[int64][]models.ActivityCheckpoint, len(checkpoints))
+	for _, cp := range checkpoints {
+		checkpointsAt[cp.Sequence] = append(checkpointsAt[cp.Sequence], cp)
+	}
+
+	fail := func(sequence int64, recordID, reason string) (*models.ChainVerificationReport, error) {
+		report.Valid = false
+		report.FirstBreak = &models.ChainBreak{Sequence: sequence, RecordID: recordID, Reason: reason}
+		return report, nil
+	}
+
+	prevHash := ""
+	if first > 1 {
+		previous, err := loadChainEntries(db, chain, first-1, first-1, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(previous) == 0 {
+			return fail(first-1, "", "record is missing")
+		}
+		prevHash = previous[0].link.Hash
+	}
+
+	expected := first
+	for expected <= last {
+		entries, err := loadChainEntries(db, chain, expected, last, chainVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return fail(expected, "", "record is missing")
+		}
+
+		for _, entry := range entries {
+			hash, chainBreak, err := checkChainEntry(chain, expected, prevHash, entry)
+			if err != nil {
+				return nil, err
+			}
+			if chainBreak != nil {
+				return fail(chainBreak.Sequence, chainBreak.RecordID, chainBreak.Reason)
+			}
+
+			for _, cp := range checkpointsAt[expected] {
+				if cp.Hash != hash {
+					return fail(expected, entry.id, "record does not match a signed checkpoint")
+				}
+				if err := verifyCheckpointSignature(db, cp); err != nil {
+					return fail(expected, entry.id, fmt.Sprintf("checkpoint %s is not authentic: %v", cp.ID, err))
+				}
+				report.CheckpointsChecked++
+			}
+
+			report.RecordsChecked++
+			prevHash = hash
+			expected++
+		}
+	}
+
+	if to == nil && head.Sequence > last {
+		// everything after the last record in range is outside the request,
+		// unless the records at the end of the chain were removed
+		var count int64
+		if err := db.Table(chain).Where("chain_sequence > ?", last).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return fail(last+1, "", "records up to the chain head are missing")
+		}
+	}
+	if to == nil && head.Sequence == last && head.Hash != prevHash {
+		return fail(last, "", "chain head does not match the last record")
+	}
+
+	return report, nil
+}
+
+// checkChainEntry checks that the entry is the live record expected at this
+// position and that it hashes onto prevHash. It returns the entry's hash, or
+// the break when the entry does not fit.
+func checkChainEntry(chain string, expected int64, prevHash string, entry chainEntry) (string, *models.ChainBreak, error) {
+	broken := func(sequence int64, recordID, reason string) (string, *models.ChainBreak, error) {
+		return "", &models.ChainBreak{Sequence: sequence, RecordID: recordID, Reason: reason}, nil
+	}
+
+	if entry.link.ChainSequence < expected {
+		return broken(entry.link.ChainSequence, entry.id, "sequence number is used more than once")
+	}
+	if entry.link.ChainSequence > expected {
+		return broken(expected, "", "record is missing")
+	}
+	if entry.deleted {
+		return broken(expected, entry.id, "record was deleted")
+	}
+	if entry.link.PrevHash != prevHash {
+		return broken(expected, entry.id, "record does not link to its predecessor")
+	}
+
+	hash, err := models.ChainHash(chain, expected, prevHash, entry.content)
+	if err != nil {
+		return "", nil, err
+	}
+	if hash != entry.link.Hash {
+		return broken(expected, entry.id, "record content does not match its hash")
+	}
+	return hash, nil, nil
+}
+
+func verifyCheckpointSignature(db *gorm.DB, checkpoint models.ActivityCheckpoint) error {
+	var claims checkpointClaims
+	_, err := jwt.ParseWithClaims(checkpoint.Signature, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		// retired keys are kept, so old checkpoints can still be checked
+		var record models.SigningKey
+		if err := db.Where("kid = ?", kid).First(&record).Error; err != nil {
+			return nil, errUnknownSigningKey
+		}
+		key, err := decodeSigningKey(record)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
+	if err != nil {
+		return err
+	}
+
+	if claims.Chain != checkpoint.Chain || claims.Sequence != checkpoint.Sequence || claims.Hash != checkpoint.Hash {
+		return errors.New("signed values differ from the stored checkpoint")
+	}
+	return nil
+}
+
+// CreateActivityCheckpoints signs the current head of every chain that has
+// moved since its last checkpoint.
+func CreateActivityCheckpoints(db *gorm.DB) error {
+	for _, chain := range models.ActivityChains {
+		var head models.ActivityChainHead
+		if err := db.Where("chain = ?", chain).Limit(1).Find(&head).Error; err != nil {
+			return err
+		}
+		if head.Sequence == 0 {
+			continue
+		}
+
+		if models.CheckExists(db, &models.ActivityCheckpoint{}, "chain = ? AND sequence = ?", chain, head.Sequence) {
+			continue
+		}
+
+		now := time.Now()
+		signature, err := keyManager.Sign(checkpointClaims{
+			Chain:    chain,
+			Sequence: head.Sequence,
+			Hash:     head.Hash,
+			RegisteredClaims: jwt.RegisteredClaims{
+				IssuedAt: jwt.NewNumericDate(now),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sign checkpoint: %v", err)
+		}
+
+		checkpoint := models.ActivityCheckpoint{
+			Chain:     chain,
+			Sequence:  head.Sequence,
+			Hash:      head.Hash,
+			Signature: signature,
+		}
+		if err := db.Create(&checkpoint).Error; err != nil {
+			return fmt.Errorf("failed to store checkpoint: %v", err)
+		}
+	}
+	return nil
+}
+
+// StartActivityCheckpoints signs chain checkpoints every
+// ACTIVITY_CHECKPOINT_INTERVAL unless ACTIVITY_CHECKPOINTS is false.
+func StartActivityCheckpoints(db *gorm.DB) {
+	if !utility.GetEnvBool("ACTIVITY_CHECKPOINTS", true) {
+		return
+	}
+	interval := utility.GetEnvDuration("ACTIVITY_CHECKPOINT_INTERVAL", time.Hour)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := CreateActivityCheckpoints(db); err != nil {
+				log.Println("Failed to create activity checkpoint:", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"survielx-backend/models"
+	"testing"
+	"time"
+)
+
+// testChain links count guest activity records the way AppendToChain does.
+func testChain(t *testing.T, count int) []chainEntry {
+	t.Helper()
+
+	entries := make([]chainEntry, 0, count)
+	prevHash := ""
+	for i := 1; i <= count; i++ {
+		activity := models.GuestVehicleActivity{
+			ID:          fmt.Sprintf("activity-%d", i),
+			PlateNumber: "ABC123",
+			IsEntry:     i%2 == 1,
+			Timestamp:   time.Date(2026, 1, 1, 8, i, 0, 0, time.UTC),
+		}
+		content := activity.ChainContent()
+		hash, err := models.ChainHash(models.ActivityChainGuest, int64(i), prevHash, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, chainEntry{
+			id:      activity.ID,
+			link:    models.ChainLink{ChainSequence: int64(i), PrevHash: prevHash, Hash: hash},
+			content: content,
+		})
+		prevHash = hash
+	}
+	return entries
+}
+
+func TestChainHash(t *testing.T) {
+	content := map[string]string{"plate_number": "ABC123"}
+	base, err := models.ChainHash(models.ActivityChainVehicle, 1, "", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		chain    string
+		sequence int64
+		prevHash string
+		content  any
+		same     bool
+	}{
+		{"same input", models.ActivityChainVehicle, 1, "", content, true},
+		{"other chain", models.ActivityChainGuest, 1, "", content, false},
+		{"other sequence", models.ActivityChainVehicle, 2, "", content, false},
+		{"other predecessor", models.ActivityChainVehicle, 1, "00", content, false},
+		{"other content", models.ActivityChainVehicle, 1, "", map[string]string{"plate_number": "ABC124"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := models.ChainHash(tt.chain, tt.sequence, tt.prevHash, tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (hash == base) != tt.same {
+				t.Errorf("hash equal to base = %v, want %v", hash == base, tt.same)
+			}
+		})
+	}
+}
+
+func TestCheckChainEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(entries []chainEntry) []chainEntry
+		sequence int64
+		reason   string
+	}{
+		{
+			name:   "intact",
+			tamper: func(e []chainEntry) []chainEntry { return e },
+		},
+		{
+			name: "content edited",
+			tamper: func(e []chainEntry) []chainEntry {
+				e[1].content = models.GuestVehicleActivity{ID: e[1].id, PlateNumber: "XYZ999"}
+				return e
+			},
+			sequence: 2,
+			reason:   "record content does not match its hash",
+		},
+		{
+			name: "record removed",
+			tamper: func(e []chainEntry) []chainEntry {
+				return append(e[:1], e[2:]...)
+			},
+			sequence: 2,
+			reason:   "record is missing",
+		},
+		{
+			name: "record soft deleted",
+			tamper: func(e []chainEntry) []chainEntry {
+				e[2].deleted = true
+				return e
+			},
+			sequence: 3,
+			reason:   "record was deleted",
+		},
+		{
+			name: "sequence reused",
+			tamper: func(e []chainEntry) []chainEntry {
+				dup := e[1]
+				return append(e[:2], dup, e[2])
+			},
+			sequence: 2,
+			reason:   "sequence number is used more than once",
+		},
+		{
+			name: "predecessor hash forged",
+			tamper: func(e []chainEntry) []chainEntry {
+				e[1].link.PrevHash = "forged"
+				return e
+			},
+			sequence: 2,
+			reason:   "record does not link to its predecessor",
+		},
+		{
+			name: "hash recomputed without its successor",
+			tamper: func(e []chainEntry) []chainEntry {
+				e[0].content = models.GuestVehicleActivity{ID: e[0].id, PlateNumber: "XYZ999"}
+				hash, _ := models.ChainHash(models.ActivityChainGuest, 1, "", e[0].content)
+				e[0].link.Hash = hash
+				return e
+			},
+			sequence: 2,
+			reason:   "record does not link to its predecessor",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(testChain(t, 3))
+
+			var chainBreak *models.ChainBreak
+			expected, prevHash := int64(1), ""
+			for _, entry := range entries {
+				hash, b, err := checkChainEntry(models.ActivityChainGuest, expected, prevHash, entry)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if b != nil {
+					chainBreak = b
+					break
+				}
+				prevHash = hash
+				expected++
+			}
+
+			if tt.reason == "" {
+				if chainBreak != nil {
+					t.Fatalf("unexpected break at %d: %s", chainBreak.Sequence, chainBreak.Reason)
+				}
+				return
+			}
+			if chainBreak == nil {
+				t.Fatalf("expected a break at %d", tt.sequence)
+			}
+			if chainBreak.Sequence != tt.sequence || chainBreak.Reason != tt.reason {
+				t.Errorf("break = %d %q, want %d %q", chainBreak.Sequence, chainBreak.Reason, tt.sequence, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"survielx-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Audited actions.
+const (
+	AuditUserRegistered           = "user.registered"
+	AuditUserEmailVerified        = "user.email_verified"
+	AuditUserPasswordChanged      = "user.password_changed"
+	AuditUserPasswordReset        = "user.password_reset"
+	AuditUserRoleChanged          = "user.role_changed"
+	AuditUserSuspended            = "user.suspended"
+	AuditUserReactivated          = "user.reactivated"
+	AuditUserPasswordResetSent    = "user.password_reset_sent"
+	AuditUserLoggedOut            = "user.logged_out"
+	AuditUserLoggedOutAll         = "user.logged_out_all"
+	AuditTwoFactorEnabled         = "two_factor.enabled"
+	AuditTwoFactorDisabled        = "two_factor.disabled"
+	AuditRecoveryCodesReset       = "two_factor.recovery_codes_regenerated"
+	AuditSessionRevoked           = "session.revoked"
+	AuditProfileUpdated           = "profile.updated"
+	AuditInvitationCreated        = "invitation.created"
+	AuditInvitationRevoked        = "invitation.revoked"
+	AuditInvitationAccepted       = "invitation.accepted"
+	AuditRolePermissionsSet       = "role.permissions_updated"
+	AuditAccountLocked            = "account.locked"
+	AuditAccountUnlocked          = "account.unlocked"
+	AuditMachineClientCreated     = "machine_client.created"
+	AuditMachineClientRotated     = "machine_client.rotated"
+	AuditMachineClientRevoked     = "machine_client.revoked"
+	AuditGateCreated              = "gate.created"
+	AuditGateUpdated              = "gate.updated"
+	AuditGateDeleted              = "gate.deleted"
+	AuditVehicleRegistered        = "vehicle.registered"
+	AuditVehicleUpdated           = "vehicle.updated"
+	AuditVehicleDeregistered      = "vehicle.deregistered"
+	AuditVehicleRestored          = "vehicle.restored"
+	AuditVehicleImported          = "vehicle.imported"
+	AuditVehicleApproved          = "vehicle.approved"
+	AuditVehicleRejected          = "vehicle.rejected"
+	AuditVehicleActivityLogged    = "vehicle.activity_logged"
+	AuditGuestActivityLogged      = "guest_vehicle.activity_logged"
+	AuditPendingExitCreated       = "pending_exit.created"
+	AuditPendingExitResolved      = "pending_exit.resolved"
+	AuditVehicleTransferInitiated = "vehicle_transfer.initiated"
+	AuditVehicleTransferAccepted  = "vehicle_transfer.accepted"
+	AuditVehicleTransferDeclined  = "vehicle_transfer.declined"
+	AuditVehicleTransferCancelled = "vehicle_transfer.cancelled"
+	AuditVehicleTransferRejected  = "vehicle_transfer.rejected"
+	AuditVehicleTransferCompleted = "vehicle_transfer.completed"
+	AuditVehicleDriverInvited     = "vehicle_driver.invited"
+	AuditVehicleDriverAccepted    = "vehicle_driver.accepted"
+	AuditVehicleDriverDeclined    = "vehicle_driver.declined"
+	AuditVehicleDriverRevoked     = "vehicle_driver.revoked"
+	AuditVehicleAttachmentAdded   = "vehicle_attachment.added"
+	AuditVehicleAttachmentRemoved = "vehicle_attachment.removed"
+	AuditVehicleTypeCreated       = "vehicle_type.created"
+	AuditVehicleTypeUpdated       = "vehicle_type.updated"
+)
+
+// Audited target types.
+const (
+	AuditTargetUser              = "user"
+	AuditTargetProfile           = "profile"
+	AuditTargetSession           = "session"
+	AuditTargetInvitation        = "invitation"
+	AuditTargetRole              = "role"
+	AuditTargetMachineClient     = "machine_client"
+	AuditTargetGate              = "access_exit_point"
+	AuditTargetVehicle           = "vehicle"
+	AuditTargetVehicleActivity   = "vehicle_activity"
+	AuditTargetGuestActivity     = "guest_vehicle_activity"
+	AuditTargetPendingExit       = "pending_vehicle_exit"
+	AuditTargetLockout           = "account_lockout"
+	AuditTargetVehicleTransfer   = "vehicle_transfer"
+	AuditTargetVehicleDriver     = "vehicle_driver"
+	AuditTargetVehicleAttachment = "vehicle_attachment"
+	AuditTargetVehicleType       = "vehicle_type"
+)
+
+// recordAudit writes an audit event with the fields that differ between
+// before and after. Either may be nil for creations and deletions. Call it
+// with the transaction making the change so both commit together.
+func recordAudit(db *gorm.DB, actor models.AuditActor, action, targetType, targetID string, before, after any) error {
+	event := models.AuditEvent{
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    auditDiff(before, after),
+		IPAddress:  actor.IPAddress,
+		RequestID:  actor.RequestID,
+	}
+	if event.ActorType == "" {
+		event.ActorType = models.AuditActorSystem
+	}
+
+	if err := db.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record audit event: %v", err)
+	}
+	return nil
+}
+
+// auditDiff compares the JSON representations of before and after, so fields
+// hidden from JSON, such as password hashes, never reach the audit log.
+func auditDiff(before, after any) map[string]models.AuditChange {
+	from := auditFields(before)
+	to := auditFields(after)
+
+	changes := make(map[string]models.AuditChange)
+	for field, value := range to {
+		if previous, ok := from[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = models.AuditChange{From: from[field], To: value}
+		}
+	}
+	for field, value := range from {
+		if _, ok := to[field]; !ok {
+			changes[field] = models.AuditChange{From: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func auditFields(v any) map[string]any {
+	fields := map[string]any{}
+	if v == nil {
+		return fields
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// not an object, record it as a single value
+		var value any
+		if json.Unmarshal(data, &value) == nil {
+			fields["value"] = value
+		}
+	}
+	return fields
+}
+
+func GetAuditEvents(db *gorm.DB, filters models.AuditFilters, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		events []models.AuditEvent
+		count  int64
+	)
+
+	query := db.Model(&models.AuditEvent{})
+	if filters.ActorID != "" {
+		query = query.Where("actor_id = ?", filters.ActorID)
+	}
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+	if filters.TargetType != "" {
+		query = query.Where("target_type = ?", filters.TargetType)
+	}
+	if filters.TargetID != "" {
+		query = query.Where("target_id = ?", filters.TargetID)
+	}
+	if filters.RequestID != "" {
+		query = query.Where("request_id = ?", filters.RequestID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at <= ?", *filters.To)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count audit events: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("created_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&events).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch audit events: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: events,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(events),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
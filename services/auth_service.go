@@ -3,18 +3,16 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
 	"survielx-backend/database"
 	"survielx-backend/models"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func Register(db *gorm.DB, user *models.User) (*models.User, int, error) {
+func Register(db *gorm.DB, actor models.AuditActor, user *models.User, client models.ClientInfo) (*models.User, int, error) {
 	var (
 		existingUser models.User
 		profile      models.Profile
@@ -57,11 +55,22 @@ func Register(db *gorm.DB, user *models.User) (*models.User, int, error) {
 		return nil, http.StatusBadRequest, fmt.Errorf("failed to create user profile: %v", err)
 	}
 
+	actor.Type, actor.ID = models.AuditActorUser, user.ID
+	if err := recordAudit(tx, actor, AuditUserRegistered, AuditTargetUser, user.ID, nil, user); err != nil {
+		tx.Rollback()
+		return nil, http.StatusInternalServerError, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, http.StatusBadRequest, errors.New("failed to commit transaction")
 	}
 
-	luser, code, err := loginAndGenerateToken(user, originalPassword)
+	if _, err := SendVerificationEmail(db, user); err != nil {
+		// the user can request a new link, so registration still succeeds
+		log.Println("Failed to send verification email on registration:", err)
+	}
+
+	luser, code, err := loginAndGenerateToken(user, originalPassword, client)
 	if err != nil {
 		return nil, code, fmt.Errorf("failed to login and generate token: %v", err)
 	}
@@ -69,57 +78,60 @@ func Register(db *gorm.DB, user *models.User) (*models.User, int, error) {
 	return luser, http.StatusCreated, nil
 }
 
-func Login(email string, password string) (*models.User, int, error) {
+func Login(email string, password string, client models.ClientInfo) (*models.User, int, error) {
+	ip := client.IPAddress
+
+	if code, err := checkLoginAllowed(email, ip); err != nil {
+		return nil, code, err
+	}
+
 	var user models.User
 	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			recordLoginFailure(database.DB, email, ip, nil)
 			return nil, http.StatusUnauthorized, errors.New("invalid email or password")
 		}
 		return nil, http.StatusBadRequest, errors.New("database error")
 	}
 
-	return loginAndGenerateToken(&user, password)
-}
-
-// loginAndGenerateToken handles user login and token generation.
-func loginAndGenerateToken(user *models.User, password string) (*models.User, int, error) {
-	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-	if err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		recordLoginFailure(database.DB, email, ip, &user.ID)
 		return nil, http.StatusUnauthorized, errors.New("invalid email or password")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 30).Unix(),
-	})
+	if user.IsSuspended() {
+		return nil, http.StatusForbidden, ErrAccountSuspended
+	}
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-	if err != nil {
-		return nil, http.StatusBadRequest, errors.New("failed to create token")
+	if user.IsTwoFactorEnabled() {
+		challenge, err := generateTwoFactorChallenge(&user)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("failed to create two-factor challenge")
+		}
+		user.TwoFactorRequired = true
+		user.TwoFactorChallenge = challenge
+		return &user, http.StatusOK, nil
 	}
 
-	user.Token = tokenString
-	return user, http.StatusOK, nil
-}
+	recordLoginSuccess(email)
 
-func RefreshToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	if code, err := startSession(database.DB, &user, client); err != nil {
+		return nil, code, err
+	}
 
-	if err != nil && err != jwt.ErrTokenExpired {
-		return "", err
+	return &user, http.StatusOK, nil
+}
+
+// loginAndGenerateToken handles user login and token generation.
+func loginAndGenerateToken(user *models.User, password string, client models.ClientInfo) (*models.User, int, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if err != nil {
+		return nil, http.StatusUnauthorized, errors.New("invalid email or password")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", jwt.ErrInvalidKey
+	if code, err := startSession(database.DB, user, client); err != nil {
+		return nil, code, err
 	}
 
-	claims["exp"] = time.Now().Add(time.Hour * 24 * 30).Unix()
-	newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return newToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return user, http.StatusOK, nil
 }
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"survielx-backend/mailer"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func emailVerificationTTL() time.Duration {
+	return utility.GetEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+}
+
+// EmailVerificationRequired reports whether unverified users are barred from
+// registering vehicles and receiving exit confirmations.
+func EmailVerificationRequired() bool {
+	return utility.GetEnvBool("REQUIRE_EMAIL_VERIFICATION", true)
+}
+
+// SendVerificationEmail emails the user a link proving they own their address.
+func SendVerificationEmail(db *gorm.DB, user *models.User) (int, error) {
+	if user.IsEmailVerified() {
+		return http.StatusBadRequest, errors.New("email is already verified")
+	}
+
+	ttl := emailVerificationTTL()
+	token, err := issueUserToken(db, user.ID, models.UserTokenPurposeEmailVerification, ttl)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to create verification token: %v", err)
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nPlease confirm that this is your email address by opening the link below within %s:\n\n%s\n\n"+
+		"If you did not create a SurvielX account, you can ignore this email.",
+		user.Name, ttl, buildTokenLink(os.Getenv("EMAIL_VERIFICATION_URL"), token))
+
+	err = mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your SurvielX email address",
+		Body:    body,
+	})
+	if err != nil {
+		log.Println("Failed to send verification email:", err)
+		return http.StatusInternalServerError, errors.New("failed to send verification email")
+	}
+
+	return http.StatusOK, nil
+}
+
+func ResendVerificationEmail(db *gorm.DB, userID string) (int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return http.StatusNotFound, errors.New("user not found")
+	}
+
+	return SendVerificationEmail(db, &user)
+}
+
+// VerifyEmail marks the owner of a verification token as verified.
+func VerifyEmail(db *gorm.DB, actor models.AuditActor, rawToken string) (int, error) {
+	token, err := consumeUserToken(db, rawToken, models.UserTokenPurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, ErrInvalidUserToken) {
+			return http.StatusBadRequest, err
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserID).
+			Update("email_verified_at", time.Now())
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		actor.Type, actor.ID = models.AuditActorUser, token.UserID
+		return recordAudit(tx, actor, AuditUserEmailVerified, AuditTargetUser, token.UserID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to verify email: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// canReceiveExitConfirmation reports whether the user may be asked to confirm
+// a vehicle exit.
+func canReceiveExitConfirmation(user *models.User) bool {
+	if user.IsSuspended() {
+		return false
+	}
+	return !EmailVerificationRequired() || user.IsEmailVerified()
+}
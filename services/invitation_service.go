@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"survielx-backend/mailer"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func invitationTTL() time.Duration {
+	return utility.GetEnvDuration("INVITATION_TTL", 7*24*time.Hour)
+}
+
+// CreateInvitation records an invitation for a privileged account and emails
+// the redemption link to the invitee.
+func CreateInvitation(db *gorm.DB, actor models.AuditActor, input models.CreateInvitationInput) (*models.Invitation, int, error) {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+
+	if models.CheckExists(db, &models.User{}, "LOWER(email) = ?", email) {
+		return nil, http.StatusConflict, errors.New("a user with this email already exists")
+	}
+
+	invitation := models.Invitation{
+		Email:       email,
+		Role:        input.Role,
+		InvitedByID: actor.ID,
+		ExpiresAt:   time.Now().Add(invitationTTL()),
+	}
+
+	if input.AccessExitPointID != "" {
+		if input.Role != models.RoleSecurity {
+			return nil, http.StatusBadRequest, errors.New("only security invitations can be assigned to a gate")
+		}
+		if !models.CheckExists(db, &models.AccessExitPoint{}, "id = ?", input.AccessExitPointID) {
+			return nil, http.StatusNotFound, fmt.Errorf("access exit point with ID %s not found", input.AccessExitPointID)
+		}
+		invitation.AccessExitPointID = &input.AccessExitPointID
+	}
+
+	rawToken, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create invitation token")
+	}
+	invitation.TokenHash = utility.HashToken(rawToken)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// a new invitation supersedes any outstanding one for the same address
+		if err := tx.Model(&models.Invitation{}).
+			Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL", email).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditInvitationCreated, AuditTargetInvitation, invitation.ID, nil, invitation)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create invitation: %v", err)
+	}
+
+	body := fmt.Sprintf("Hello,\n\nYou have been invited to join SurvielX as %s.\n"+
+		"Use the link below before %s to create your account:\n\n%s\n",
+		invitation.Role, invitation.ExpiresAt.Format(time.RFC1123), buildTokenLink(os.Getenv("INVITATION_URL"), rawToken))
+
+	err = mailer.Send(mailer.Message{
+		To:      []string{invitation.Email},
+		Subject: "You have been invited to SurvielX",
+		Body:    body,
+	})
+	if err != nil {
+		log.Println("Failed to send invitation email:", err)
+		return nil, http.StatusInternalServerError, errors.New("invitation created but the email could not be sent")
+	}
+
+	return &invitation, http.StatusCreated, nil
+}
+
+func GetInvitations(db *gorm.DB, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		invitations []models.Invitation
+		count       int64
+	)
+
+	query := db.Model(&models.Invitation{})
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count invitations: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Preload("AccessExitPoint").
+		Order("created_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&invitations).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch invitations: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: invitations,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(invitations),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
+
+func RevokeInvitation(db *gorm.DB, actor models.AuditActor, invitationID string) (int, error) {
+	var invitation models.Invitation
+	if !models.CheckExists(db, &invitation, "id = ?", invitationID) {
+		return http.StatusNotFound, errors.New("invitation not found")
+	}
+
+	if !invitation.IsPending() {
+		return http.StatusBadRequest, errors.New("invitation is no longer pending")
+	}
+
+	before := invitation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditInvitationRevoked, AuditTargetInvitation, invitation.ID, before, invitation)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to revoke invitation: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// AcceptInvitation redeems an invitation token, creating the invited user with
+// the role and gate assignment chosen by the admin, and logs them in.
+func AcceptInvitation(db *gorm.DB, actor models.AuditActor, input models.AcceptInvitationInput, client models.ClientInfo) (*models.User, int, error) {
+	var invitation models.Invitation
+	err := db.Where("token_hash = ?", utility.HashToken(input.Token)).First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusBadRequest, errors.New("invitation is invalid")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if !invitation.IsPending() {
+		return nil, http.StatusBadRequest, errors.New("invitation has expired or was already used")
+	}
+
+	if models.CheckExists(db, &models.User{}, "LOWER(email) = ?", invitation.Email) {
+		return nil, http.StatusConflict, errors.New("a user with this email already exists")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("failed to hash password")
+	}
+
+	// the invitation was delivered to this address, which proves ownership
+	now := time.Now()
+	user := models.User{
+		Name:            input.Name,
+		Email:           invitation.Email,
+		Password:        string(hashedPassword),
+		Role:            invitation.Role,
+		EmailVerifiedAt: &now,
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.ID).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invitation was already used")
+		}
+
+		if err := user.CreateUser(tx); err != nil {
+			return fmt.Errorf("failed to create user: %v", err)
+		}
+
+		profile := models.Profile{UserID: user.ID, FullName: user.Name}
+		if err := profile.CreateProfile(tx); err != nil {
+			return fmt.Errorf("failed to create user profile: %v", err)
+		}
+
+		if user.Role == models.RoleSecurity {
+			security := models.Security{UserID: user.ID, AccessExitPointID: invitation.AccessExitPointID}
+			if err := tx.Create(&security).Error; err != nil {
+				return fmt.Errorf("failed to assign security gate: %v", err)
+			}
+		}
+
+		if err := tx.Model(&models.Invitation{}).Where("id = ?", invitation.ID).Update("accepted_user_id", user.ID).Error; err != nil {
+			return err
+		}
+
+		actor.Type, actor.ID = models.AuditActorUser, user.ID
+		return recordAudit(tx, actor, AuditInvitationAccepted, AuditTargetInvitation, invitation.ID, nil, user)
+	})
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if code, err := startSession(db, &user, client); err != nil {
+		return nil, code, err
+	}
+
+	return &user, http.StatusCreated, nil
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var errUnknownSigningKey = errors.New("unknown signing key")
+
+// keyReloadInterval limits how often a token with an unknown kid may reload
+// the keys, so made-up kids cannot be used to flood the database.
+const keyReloadInterval = time.Minute
+
+type signingKey struct {
+	kid         string
+	algorithm   string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	public      crypto.PublicKey
+	signUntil   time.Time
+	verifyUntil time.Time
+}
+
+// KeyManager holds the asymmetric keys used to sign and verify tokens. Keys
+// are persisted so every instance signs with the same key and can verify
+// tokens issued by the others.
+type KeyManager struct {
+	mu        sync.RWMutex
+	reloadMu  sync.Mutex
+	db        *gorm.DB
+	algorithm string
+	keys      map[string]*signingKey
+	current   *signingKey
+	loadedAt  time.Time
+}
+
+var keyManager *KeyManager
+
+func keyRotationInterval() time.Duration {
+	return utility.GetEnvDuration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour)
+}
+
+// keys stay verifiable after they stop signing for longer than any token
+// they could have signed lives
+func keyVerificationGrace() time.Duration {
+	return utility.GetEnvDuration("JWT_KEY_VERIFICATION_GRACE", 24*time.Hour)
+}
+
+// InitKeyManager loads the signing keys from the database, creating the first
+// key if needed. The algorithm for new keys is set with JWT_SIGNING_ALG
+// (RS256 or EdDSA).
+func InitKeyManager(db *gorm.DB) error {
+	algorithm := os.Getenv("JWT_SIGNING_ALG")
+	switch algorithm {
+	case "":
+		algorithm = AlgorithmRS256
+	case AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return fmt.Errorf("unsupported JWT_SIGNING_ALG %q", algorithm)
+	}
+
+	km := &KeyManager{db: db, algorithm: algorithm, keys: make(map[string]*signingKey)}
+	if err := km.Rotate(false); err != nil {
+		return err
+	}
+
+	keyManager = km
+	return nil
+}
+
+// Rotate reloads the keys and creates a new signing key when forced or when
+// no loaded key may sign anymore.
+func (km *KeyManager) Rotate(force bool) error {
+	if err := km.reload(); err != nil {
+		return err
+	}
+
+	km.mu.RLock()
+	current := km.current
+	km.mu.RUnlock()
+
+	if !force && current != nil && current.algorithm == km.algorithm {
+		return nil
+	}
+
+	record, err := generateSigningKey(km.algorithm)
+	if err != nil {
+		return err
+	}
+	if err := km.db.Create(record).Error; err != nil {
+		return fmt.Errorf("failed to persist signing key: %v", err)
+	}
+
+	// the previous key stops signing now but remains verifiable
+	if current != nil {
+		verifyUntil := time.Now().Add(keyVerificationGrace())
+		if err := km.db.Model(&models.SigningKey{}).
+			Where("kid = ?", current.kid).
+			Updates(map[string]any{"sign_until": time.Now(), "verify_until": verifyUntil}).Error; err != nil {
+			return fmt.Errorf("failed to retire signing key: %v", err)
+		}
+	}
+
+	log.Printf("Rotated token signing key, new kid %s", record.KID)
+	return km.reload()
+}
+
+func (km *KeyManager) reload() error {
+	var records []models.SigningKey
+	now := time.Now()
+
+	km.mu.Lock()
+	km.loadedAt = now
+	km.mu.Unlock()
+
+	if err := km.db.Where("verify_until > ?", now).Order("created_at asc").Find(&records).Error; err != nil {
+		return fmt.Errorf("failed to load signing keys: %v", err)
+	}
+
+	keys := make(map[string]*signingKey, len(records))
+	var current *signingKey
+	for _, record := range records {
+		key, err := decodeSigningKey(record)
+		if err != nil {
+			log.Printf("Skipping signing key %s: %v", record.KID, err)
+			continue
+		}
+		keys[key.kid] = key
+		if now.Before(key.signUntil) {
+			current = key
+		}
+	}
+
+	km.mu.Lock()
+	km.keys = keys
+	km.current = current
+	km.mu.Unlock()
+	return nil
+}
+
+// Sign signs the claims with the current key and sets the kid header.
+func (km *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	km.mu.RLock()
+	current := km.current
+	km.mu.RUnlock()
+
+	if current == nil {
+		return "", errors.New("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(current.method, claims)
+	token.Header["kid"] = current.kid
+	return token.SignedString(current.private)
+}
+
+// Keyfunc resolves the verification key for a token from its kid header.
+func (km *KeyManager) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errUnknownSigningKey
+	}
+
+	key := km.lookup(kid)
+	if key == nil {
+		// another instance may have rotated since we last loaded
+		if err := km.reloadIfStale(); err != nil {
+			return nil, err
+		}
+		if key = km.lookup(kid); key == nil {
+			return nil, errUnknownSigningKey
+		}
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// reloadIfStale reloads the keys unless they were loaded within
+// keyReloadInterval. Concurrent callers wait for a single reload.
+func (km *KeyManager) reloadIfStale() error {
+	km.reloadMu.Lock()
+	defer km.reloadMu.Unlock()
+
+	km.mu.RLock()
+	loadedAt := km.loadedAt
+	km.mu.RUnlock()
+
+	if time.Since(loadedAt) < keyReloadInterval {
+		return nil
+	}
+	return km.reload()
+}
+
+func (km *KeyManager) lookup(kid string) *signingKey {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	key, ok := km.keys[kid]
+	if !ok || time.Now().After(key.verifyUntil) {
+		return nil
+	}
+	return key
+}
+
+// JWKS returns every public key that can currently verify a token.
+func (km *KeyManager) JWKS() models.JWKS {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	jwks := models.JWKS{Keys: []models.JWK{}}
+	now := time.Now()
+	for _, key := range km.keys {
+		if now.After(key.verifyUntil) {
+			continue
+		}
+
+		jwk := models.JWK{Kid: key.kid, Use: "sig", Alg: key.algorithm}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// GetJWKS returns the published verification keys.
+func GetJWKS() models.JWKS {
+	return keyManager.JWKS()
+}
+
+// StartKeyRotation checks periodically whether the signing key is due for
+// rotation.
+func StartKeyRotation() {
+	interval := utility.GetEnvDuration("JWT_KEY_ROTATION_CHECK_INTERVAL", time.Hour)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := keyManager.Rotate(false); err != nil {
+				log.Println("Failed to rotate signing key:", err)
+			}
+		}
+	}()
+}
+
+func generateSigningKey(algorithm string) (*models.SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %v", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := utility.GenerateSecureToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	privatePEM, err := utility.EncryptString(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key: %v", err)
+	}
+
+	now := time.Now()
+	signUntil := now.Add(keyRotationInterval())
+	return &models.SigningKey{
+		KID:         kid,
+		Algorithm:   algorithm,
+		PrivateKey:  privatePEM,
+		PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		SignUntil:   signUntil,
+		VerifyUntil: signUntil.Add(keyVerificationGrace()),
+	}, nil
+}
+
+func decodeSigningKey(record models.SigningKey) (*signingKey, error) {
+	privatePEM, err := utility.DecryptString(record.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %v", err)
+	}
+
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("invalid private key encoding")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		kid:         record.KID,
+		algorithm:   record.Algorithm,
+		signUntil:   record.SignUntil,
+		verifyUntil: record.VerifyUntil,
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method = jwt.SigningMethodRS256
+		key.private = private
+		key.public = &private.PublicKey
+	case ed25519.PrivateKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.private = private
+		key.public = private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if key.method.Alg() != record.Algorithm {
+		return nil, fmt.Errorf("key type does not match algorithm %s", record.Algorithm)
+	}
+	return key, nil
+}
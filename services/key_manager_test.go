@@ -0,0 +1,92 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestKeyManager returns a manager holding one freshly generated key. Its
+// db is nil, so any reload from the database panics.
+func newTestKeyManager(t *testing.T, algorithm string) *KeyManager {
+	t.Helper()
+	t.Setenv("DATA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+
+	record, err := generateSigningKey(algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(record.PrivateKey, "PRIVATE KEY") {
+		t.Fatal("private key is stored as plaintext PEM")
+	}
+
+	key, err := decodeSigningKey(*record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &KeyManager{
+		algorithm: algorithm,
+		keys:      map[string]*signingKey{key.kid: key},
+		current:   key,
+		loadedAt:  time.Now(),
+	}
+}
+
+func TestParseTokenChecksIssuerAndAudience(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		keyManager = newTestKeyManager(t, algorithm)
+
+		tests := []struct {
+			name   string
+			modify func(jwt.MapClaims)
+			valid  bool
+		}{
+			{"issued here", func(jwt.MapClaims) {}, true},
+			{"other issuer", func(c jwt.MapClaims) { c["iss"] = "elsewhere" }, false},
+			{"other audience", func(c jwt.MapClaims) { c["aud"] = "elsewhere" }, false},
+			{"no issuer", func(c jwt.MapClaims) { delete(c, "iss") }, false},
+			{"no audience", func(c jwt.MapClaims) { delete(c, "aud") }, false},
+			{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }, false},
+			{"other type", func(c jwt.MapClaims) { c["typ"] = "refresh" }, false},
+		}
+		for _, tt := range tests {
+			t.Run(algorithm+"/"+tt.name, func(t *testing.T) {
+				claims := newTokenClaims("user-1", accessTokenType, time.Minute)
+				tt.modify(claims)
+				token, err := keyManager.Sign(claims)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, err = ParseAccessToken(token)
+				if (err == nil) != tt.valid {
+					t.Fatalf("ParseAccessToken error = %v, want valid %v", err, tt.valid)
+				}
+			})
+		}
+	}
+}
+
+func TestKeyfuncThrottlesReloads(t *testing.T) {
+	km := newTestKeyManager(t, AlgorithmEdDSA)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{})
+	token.Header["kid"] = "made-up"
+
+	// keys were just loaded, so an unknown kid must not reach the nil db
+	for range 100 {
+		if _, err := km.Keyfunc(token); !errors.Is(err, errUnknownSigningKey) {
+			t.Fatalf("Keyfunc error = %v, want errUnknownSigningKey", err)
+		}
+	}
+
+	token.Method = jwt.SigningMethodRS256
+	token.Header["kid"] = km.current.kid
+	if _, err := km.Keyfunc(token); !errors.Is(err, jwt.ErrSignatureInvalid) {
+		t.Fatalf("Keyfunc accepted a token for another algorithm: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"survielx-backend/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptStore keeps failed login counters and lockouts per key, where a
+// key identifies either an account or a client IP.
+type LoginAttemptStore interface {
+	// RecordFailure increments the key's counter and returns the new count.
+	// Failures older than window no longer count.
+	RecordFailure(key string, window time.Duration) (int, error)
+	// Failures returns the key's count and the time of its latest failure.
+	Failures(key string, window time.Duration) (int, time.Time, error)
+	Lock(key string, until time.Time) error
+	// LockedUntil returns the end of the key's lockout, or the zero time.
+	LockedUntil(key string) (time.Time, error)
+	Reset(key string) error
+}
+
+var loginAttempts LoginAttemptStore = NewMemoryLoginAttemptStore()
+
+// InitLoginAttemptStore selects the store through LOGIN_ATTEMPT_STORE
+// ("memory" or "postgres"). The Postgres store shares counters between
+// instances.
+func InitLoginAttemptStore(db *gorm.DB) {
+	switch os.Getenv("LOGIN_ATTEMPT_STORE") {
+	case "postgres":
+		loginAttempts = NewPostgresLoginAttemptStore(db)
+	case "", "memory":
+		loginAttempts = NewMemoryLoginAttemptStore()
+	default:
+		log.Printf("Unknown LOGIN_ATTEMPT_STORE %q, using in-memory store", os.Getenv("LOGIN_ATTEMPT_STORE"))
+		loginAttempts = NewMemoryLoginAttemptStore()
+	}
+}
+
+type attemptState struct {
+	count        int
+	firstFailure time.Time
+	lastFailure  time.Time
+	window       time.Duration
+	lockedUntil  time.Time
+}
+
+// expired reports whether the state neither counts failures nor locks anything.
+func (state *attemptState) expired(now time.Time) bool {
+	return now.Sub(state.firstFailure) > state.window && !now.Before(state.lockedUntil)
+}
+
+// memoryStorePruneInterval is how often the memory store drops expired keys.
+const memoryStorePruneInterval = time.Minute
+
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*attemptState
+	pruned   time.Time
+	now      func() time.Time
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: make(map[string]*attemptState), now: time.Now}
+}
+
+// prune drops expired keys, so failures for random emails and addresses do
+// not pile up. It runs at most once per memoryStorePruneInterval and expects
+// s.mu to be held.
+func (s *MemoryLoginAttemptStore) prune(now time.Time) {
+	if now.Sub(s.pruned) < memoryStorePruneInterval {
+		return
+	}
+	s.pruned = now
+
+	for key, state := range s.attempts {
+		if state.expired(now) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+func (s *MemoryLoginAttemptStore) RecordFailure(key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	state, ok := s.attempts[key]
+	if !ok {
+		state = &attemptState{}
+		s.attempts[key] = state
+	}
+	if state.count == 0 || now.Sub(state.firstFailure) > window {
+		state.count = 0
+		state.firstFailure = now
+	}
+	state.window = window
+	state.lastFailure = now
+	state.count++
+	return state.count, nil
+}
+
+func (s *MemoryLoginAttemptStore) Failures(key string, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.attempts[key]
+	if !ok || s.now().Sub(state.firstFailure) > window {
+		return 0, time.Time{}, nil
+	}
+	return state.count, state.lastFailure, nil
+}
+
+func (s *MemoryLoginAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(s.now())
+	state, ok := s.attempts[key]
+	if !ok {
+		state = &attemptState{}
+		s.attempts[key] = state
+	}
+	state.lockedUntil = until
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) LockedUntil(key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.attempts[key]
+	if !ok {
+		return time.Time{}, nil
+	}
+	return state.lockedUntil, nil
+}
+
+func (s *MemoryLoginAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+type PostgresLoginAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresLoginAttemptStore(db *gorm.DB) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{db: db}
+}
+
+func (s *PostgresLoginAttemptStore) RecordFailure(key string, window time.Duration) (int, error) {
+	var count int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginAttempt{Key: key, FirstFailureAt: now, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+
+		var attempt models.LoginAttempt
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&attempt).Error; err != nil {
+			return err
+		}
+
+		if attempt.FailureCount == 0 || now.Sub(attempt.FirstFailureAt) > window {
+			attempt.FailureCount = 0
+			attempt.FirstFailureAt = now
+		}
+		attempt.FailureCount++
+		attempt.LastFailureAt = now
+		count = attempt.FailureCount
+
+		return tx.Model(&models.LoginAttempt{}).Where("key = ?", key).Updates(map[string]any{
+			"failure_count":    attempt.FailureCount,
+			"first_failure_at": attempt.FirstFailureAt,
+			"last_failure_at":  attempt.LastFailureAt,
+		}).Error
+	})
+	return count, err
+}
+
+func (s *PostgresLoginAttemptStore) Failures(key string, window time.Duration) (int, time.Time, error) {
+	var attempt models.LoginAttempt
+	if err := s.db.Where("key = ?", key).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, time.Time{}, nil
+		}
+		return 0, time.Time{}, err
+	}
+	if time.Since(attempt.FirstFailureAt) > window {
+		return 0, time.Time{}, nil
+	}
+	return attempt.FailureCount, attempt.LastFailureAt, nil
+}
+
+func (s *PostgresLoginAttemptStore) Lock(key string, until time.Time) error {
+	attempt := models.LoginAttempt{Key: key, FirstFailureAt: time.Now(), LastFailureAt: time.Now(), LockedUntil: &until}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locked_until"}),
+	}).Create(&attempt).Error
+}
+
+func (s *PostgresLoginAttemptStore) LockedUntil(key string) (time.Time, error) {
+	var attempt models.LoginAttempt
+	if err := s.db.Where("key = ?", key).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	if attempt.LockedUntil == nil {
+		return time.Time{}, nil
+	}
+	return *attempt.LockedUntil, nil
+}
+
+func (s *PostgresLoginAttemptStore) Reset(key string) error {
+	return s.db.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryLoginAttemptStorePrunesExpiredKeys(t *testing.T) {
+	start := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	window := 15 * time.Minute
+
+	tests := []struct {
+		name   string
+		setup  func(s *MemoryLoginAttemptStore)
+		after  time.Duration
+		remain bool
+	}{
+		{
+			name:   "failures within the window",
+			setup:  func(s *MemoryLoginAttemptStore) { s.RecordFailure("ip:a", window) },
+			after:  10 * time.Minute,
+			remain: true,
+		},
+		{
+			name:  "failures past the window",
+			setup: func(s *MemoryLoginAttemptStore) { s.RecordFailure("ip:a", window) },
+			after: 16 * time.Minute,
+		},
+		{
+			name: "lockout outlasting the window",
+			setup: func(s *MemoryLoginAttemptStore) {
+				s.RecordFailure("ip:a", window)
+				s.Lock("ip:a", start.Add(time.Hour))
+			},
+			after:  30 * time.Minute,
+			remain: true,
+		},
+		{
+			name: "lockout over",
+			setup: func(s *MemoryLoginAttemptStore) {
+				s.RecordFailure("ip:a", window)
+				s.Lock("ip:a", start.Add(time.Hour))
+			},
+			after: 61 * time.Minute,
+		},
+		{
+			name:  "lock without failures",
+			setup: func(s *MemoryLoginAttemptStore) { s.Lock("ip:a", start.Add(time.Hour)) },
+			after: 61 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			store := NewMemoryLoginAttemptStore()
+			store.now = func() time.Time { return now }
+
+			tt.setup(store)
+			now = now.Add(tt.after)
+			// any write sweeps the store
+			store.RecordFailure("ip:b", window)
+
+			if _, ok := store.attempts["ip:a"]; ok != tt.remain {
+				t.Errorf("key kept = %v, want %v", ok, tt.remain)
+			}
+		})
+	}
+}
+
+func TestMemoryLoginAttemptStoreCountsWithinWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	store := NewMemoryLoginAttemptStore()
+	store.now = func() time.Time { return now }
+	window := 15 * time.Minute
+
+	for want := 1; want <= 3; want++ {
+		if got, _ := store.RecordFailure("account:a", window); got != want {
+			t.Fatalf("count = %d, want %d", got, want)
+		}
+	}
+
+	now = now.Add(16 * time.Minute)
+	if got, _, _ := store.Failures("account:a", window); got != 0 {
+		t.Errorf("failures after window = %d, want 0", got)
+	}
+	if got, _ := store.RecordFailure("account:a", window); got != 1 {
+		t.Errorf("count after window = %d, want 1", got)
+	}
+}
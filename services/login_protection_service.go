@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loginPolicy struct {
+	maxAccountFailures int
+	maxIPFailures      int
+	window             time.Duration
+	lockoutDuration    time.Duration
+	delayBase          time.Duration
+	delayMax           time.Duration
+}
+
+func currentLoginPolicy() loginPolicy {
+	return loginPolicy{
+		maxAccountFailures: utility.GetEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		maxIPFailures:      utility.GetEnvInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+		window:             utility.GetEnvDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		lockoutDuration:    utility.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		delayBase:          utility.GetEnvDuration("LOGIN_DELAY_BASE", 500*time.Millisecond),
+		delayMax:           utility.GetEnvDuration("LOGIN_DELAY_MAX", 5*time.Second),
+	}
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginThrottledError rejects a login while the account or client IP is
+// locked out or has failed too recently.
+type LoginThrottledError struct {
+	// RetryAfter is how long the client should wait before trying again.
+	RetryAfter time.Duration
+	message    string
+}
+
+func (e *LoginThrottledError) Error() string {
+	return e.message
+}
+
+// checkLoginAllowed rejects the attempt while the account or client IP is
+// locked out, or until the delay after its recent failures has passed. The
+// attempt is turned away rather than held, so repeated failures cost the
+// server nothing.
+func checkLoginAllowed(email, ip string) (int, error) {
+	policy := currentLoginPolicy()
+	now := time.Now()
+
+	var wait time.Duration
+	for _, key := range []string{accountAttemptKey(email), ipAttemptKey(ip)} {
+		until, err := loginAttempts.LockedUntil(key)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to check login lockout: %v", err)
+		}
+		if now.Before(until) {
+			return http.StatusTooManyRequests, &LoginThrottledError{
+				RetryAfter: until.Sub(now),
+				message:    fmt.Sprintf("too many failed login attempts, try again after %s", until.Format(time.RFC3339)),
+			}
+		}
+
+		failures, last, err := loginAttempts.Failures(key, policy.window)
+		if err != nil {
+			log.Println("Failed to read login failures:", err)
+			continue
+		}
+		wait = max(wait, policy.retryAfter(failures, last, now))
+	}
+
+	if wait > 0 {
+		return http.StatusTooManyRequests, &LoginThrottledError{
+			RetryAfter: wait,
+			message:    fmt.Sprintf("too many failed login attempts, try again in %s", wait.Round(time.Second)),
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// retryAfter is how long after now a key with the given recent failures must
+// wait. The delay after the last failure grows exponentially with their
+// number, capped at LOGIN_DELAY_MAX.
+func (policy loginPolicy) retryAfter(failures int, last, now time.Time) time.Duration {
+	if failures == 0 {
+		return 0
+	}
+
+	delay := time.Duration(float64(policy.delayBase) * math.Pow(2, float64(failures-1)))
+	return max(0, last.Add(min(delay, policy.delayMax)).Sub(now))
+}
+
+// recordLoginFailure counts a failed attempt against both the account and the
+// client IP and locks whichever crosses its threshold.
+func recordLoginFailure(db *gorm.DB, email, ip string, userID *string) {
+	policy := currentLoginPolicy()
+
+	scopes := []struct {
+		scope models.LockoutScope
+		key   string
+		limit int
+	}{
+		{models.LockoutScopeAccount, accountAttemptKey(email), policy.maxAccountFailures},
+		{models.LockoutScopeIP, ipAttemptKey(ip), policy.maxIPFailures},
+	}
+
+	for _, s := range scopes {
+		count, err := loginAttempts.RecordFailure(s.key, policy.window)
+		if err != nil {
+			log.Println("Failed to record login failure:", err)
+			continue
+		}
+		if s.limit <= 0 || count < s.limit {
+			continue
+		}
+
+		until := time.Now().Add(policy.lockoutDuration)
+		if err := loginAttempts.Lock(s.key, until); err != nil {
+			log.Println("Failed to lock out login:", err)
+			continue
+		}
+
+		lockout := models.AccountLockout{
+			Scope:        s.scope,
+			Email:        strings.ToLower(strings.TrimSpace(email)),
+			IPAddress:    ip,
+			FailureCount: count,
+			LockedUntil:  until,
+		}
+		if s.scope == models.LockoutScopeAccount {
+			lockout.UserID = userID
+		}
+		actor := models.SystemActor()
+		actor.IPAddress = ip
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&lockout).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, actor, AuditAccountLocked, AuditTargetLockout, lockout.ID, nil, lockout)
+		})
+		if err != nil {
+			log.Println("Failed to record lockout:", err)
+		}
+		log.Printf("Login locked out (%s) for %s from %s until %s", s.scope, email, ip, until.Format(time.RFC3339))
+	}
+}
+
+func recordLoginSuccess(email string) {
+	if err := loginAttempts.Reset(accountAttemptKey(email)); err != nil {
+		log.Println("Failed to reset login failures:", err)
+	}
+}
+
+// UnlockAccount lifts the lockout on a user's account and, optionally, on a
+// client IP.
+func UnlockAccount(db *gorm.DB, actor models.AuditActor, userID, ip string) (int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusNotFound, errors.New("user not found")
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	if err := loginAttempts.Reset(accountAttemptKey(user.Email)); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to unlock account: %v", err)
+	}
+
+	if ip != "" {
+		if err := loginAttempts.Reset(ipAttemptKey(ip)); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to unlock ip address: %v", err)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.AccountLockout{}).Where("unlocked_at IS NULL")
+		if ip != "" {
+			query = query.Where("(scope = ? AND email = ?) OR (scope = ? AND ip_address = ?)",
+				models.LockoutScopeAccount, strings.ToLower(user.Email), models.LockoutScopeIP, ip)
+		} else {
+			query = query.Where("scope = ? AND email = ?", models.LockoutScopeAccount, strings.ToLower(user.Email))
+		}
+
+		if err := query.Updates(map[string]any{"unlocked_at": time.Now(), "unlocked_by_id": actor.ID}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditAccountUnlocked, AuditTargetUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to update lockout records: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+func GetAccountLockouts(db *gorm.DB, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		lockouts []models.AccountLockout
+		count    int64
+	)
+
+	query := db.Model(&models.AccountLockout{})
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count lockouts: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("created_at desc").Offset(offset).Limit(pagination.Limit).Find(&lockouts).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch lockouts: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: lockouts,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(lockouts),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
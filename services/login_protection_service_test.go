@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLoginPolicyRetryAfter(t *testing.T) {
+	policy := loginPolicy{delayBase: 500 * time.Millisecond, delayMax: 5 * time.Second}
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		last     time.Time
+		want     time.Duration
+	}{
+		{name: "no failures", want: 0},
+		{name: "first failure just now", failures: 1, last: now, want: 500 * time.Millisecond},
+		{name: "third failure a second ago", failures: 3, last: now.Add(-time.Second), want: time.Second},
+		{name: "delay capped", failures: 10, last: now, want: 5 * time.Second},
+		{name: "delay served", failures: 2, last: now.Add(-2 * time.Second), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.retryAfter(tt.failures, tt.last, now); got != tt.want {
+				t.Errorf("retryAfter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLoginAllowed(t *testing.T) {
+	defer func(store LoginAttemptStore) { loginAttempts = store }(loginAttempts)
+	t.Setenv("LOGIN_DELAY_BASE", "1m")
+	t.Setenv("LOGIN_DELAY_MAX", "1h")
+
+	tests := []struct {
+		name  string
+		setup func(s *MemoryLoginAttemptStore)
+		code  int
+		min   time.Duration
+	}{
+		{name: "no failures", setup: func(s *MemoryLoginAttemptStore) {}, code: http.StatusOK},
+		{
+			name:  "account failed recently",
+			setup: func(s *MemoryLoginAttemptStore) { s.RecordFailure(accountAttemptKey("Ada@Example.com"), time.Hour) },
+			code:  http.StatusTooManyRequests,
+			min:   59 * time.Second,
+		},
+		{
+			name:  "ip failed recently",
+			setup: func(s *MemoryLoginAttemptStore) { s.RecordFailure(ipAttemptKey("203.0.113.7"), time.Hour) },
+			code:  http.StatusTooManyRequests,
+			min:   59 * time.Second,
+		},
+		{
+			name:  "ip locked out",
+			setup: func(s *MemoryLoginAttemptStore) { s.Lock(ipAttemptKey("203.0.113.7"), time.Now().Add(time.Hour)) },
+			code:  http.StatusTooManyRequests,
+			min:   59 * time.Minute,
+		},
+		{
+			name:  "other ip failed",
+			setup: func(s *MemoryLoginAttemptStore) { s.RecordFailure(ipAttemptKey("198.51.100.9"), time.Hour) },
+			code:  http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryLoginAttemptStore()
+			tt.setup(store)
+			loginAttempts = store
+
+			code, err := checkLoginAllowed("ada@example.com", "203.0.113.7")
+			if code != tt.code {
+				t.Fatalf("code = %d, want %d (err %v)", code, tt.code, err)
+			}
+			if tt.code == http.StatusOK {
+				return
+			}
+
+			var throttled *LoginThrottledError
+			if !errors.As(err, &throttled) {
+				t.Fatalf("err = %v, want LoginThrottledError", err)
+			}
+			if throttled.RetryAfter < tt.min {
+				t.Errorf("RetryAfter = %s, want at least %s", throttled.RetryAfter, tt.min)
+			}
+		})
+	}
+}
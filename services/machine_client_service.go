@@ -0,0 +1,259 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+func machineSignatureMaxSkew() time.Duration {
+	return utility.GetEnvDuration("MACHINE_SIGNATURE_MAX_SKEW", 5*time.Minute)
+}
+
+func machineSecretRotationGrace() time.Duration {
+	return utility.GetEnvDuration("MACHINE_SECRET_ROTATION_GRACE", 24*time.Hour)
+}
+
+// CreateMachineClient registers a machine client and returns it with its
+// secret. The secret is only ever shown here and on rotation.
+func CreateMachineClient(db *gorm.DB, actor models.AuditActor, input models.CreateMachineClientInput) (*models.MachineClient, int, error) {
+	gates := uniqueStrings(input.AllowedGates)
+	for _, gateID := range gates {
+		if !models.CheckExists(db, &models.AccessExitPoint{}, "id = ?", gateID) {
+			return nil, http.StatusNotFound, fmt.Errorf("access exit point with ID %s not found", gateID)
+		}
+	}
+
+	keyID, err := utility.GenerateSecureToken(12)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create key ID")
+	}
+	secret, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create secret")
+	}
+	signingKey, err := utility.EncryptString(utility.HashToken(secret))
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to protect secret: %v", err)
+	}
+
+	client := models.MachineClient{
+		Name:         input.Name,
+		KeyID:        "mk_" + keyID,
+		SigningKey:   signingKey,
+		AllowedGates: gates,
+		Scopes:       uniqueStrings(input.Scopes),
+		CreatedByID:  actor.ID,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&client).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditMachineClientCreated, AuditTargetMachineClient, client.ID, nil, client)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create machine client: %v", err)
+	}
+
+	client.Secret = secret
+	return &client, http.StatusCreated, nil
+}
+
+func GetMachineClients(db *gorm.DB, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		clients []models.MachineClient
+		count   int64
+	)
+
+	query := db.Model(&models.MachineClient{})
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count machine clients: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("created_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&clients).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch machine clients: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: clients,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(clients),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
+
+// RotateMachineClientSecret issues a new secret. The previous secret keeps
+// working for MACHINE_SECRET_ROTATION_GRACE so the client can be redeployed.
+func RotateMachineClientSecret(db *gorm.DB, actor models.AuditActor, clientID string) (*models.MachineClient, int, error) {
+	var client models.MachineClient
+	if !models.CheckExists(db, &client, "id = ?", clientID) {
+		return nil, http.StatusNotFound, errors.New("machine client not found")
+	}
+	if client.RevokedAt != nil {
+		return nil, http.StatusBadRequest, errors.New("machine client has been revoked")
+	}
+
+	secret, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create secret")
+	}
+	signingKey, err := utility.EncryptString(utility.HashToken(secret))
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to protect secret: %v", err)
+	}
+
+	now := time.Now()
+	graceUntil := now.Add(machineSecretRotationGrace())
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&client).Updates(map[string]any{
+			"previous_signing_key":       client.SigningKey,
+			"previous_secret_expires_at": graceUntil,
+			"signing_key":                signingKey,
+			"rotated_at":                 now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditMachineClientRotated, AuditTargetMachineClient, client.ID, nil, nil)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to rotate secret: %v", err)
+	}
+
+	client.Secret = secret
+	return &client, http.StatusOK, nil
+}
+
+func RevokeMachineClient(db *gorm.DB, actor models.AuditActor, clientID string) (int, error) {
+	var client models.MachineClient
+	if !models.CheckExists(db, &client, "id = ?", clientID) {
+		return http.StatusNotFound, errors.New("machine client not found")
+	}
+	if client.RevokedAt != nil {
+		return http.StatusBadRequest, errors.New("machine client is already revoked")
+	}
+
+	before := client
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&client).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditMachineClientRevoked, AuditTargetMachineClient, client.ID, before, client)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to revoke machine client: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// MachineRequestSignature computes the signature a client must send for a
+// request. The HMAC-SHA256 key is the hex SHA-256 digest of the client secret,
+// which the server keeps encrypted, and the signed string is:
+//
+//	METHOD\nREQUEST_URI\nTIMESTAMP\nNONCE\nhex(sha256(body))
+func MachineRequestSignature(signingKey, method, requestURI, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	payload := strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		timestamp,
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuthenticateMachineRequest verifies a signed request from a machine client
+// and records its nonce so the same request cannot be replayed.
+func AuthenticateMachineRequest(db *gorm.DB, keyID, timestamp, nonce, signature, method, requestURI string, body []byte) (*models.MachineClient, int, error) {
+	if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, http.StatusUnauthorized, errors.New("missing request signature headers")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, http.StatusUnauthorized, errors.New("invalid request timestamp")
+	}
+	sentAt := time.Unix(unix, 0)
+	maxSkew := machineSignatureMaxSkew()
+	if time.Since(sentAt).Abs() > maxSkew {
+		return nil, http.StatusUnauthorized, errors.New("request timestamp is outside the accepted window")
+	}
+
+	var client models.MachineClient
+	if err := db.Where("key_id = ?", keyID).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusUnauthorized, ErrInvalidSignature
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	if client.RevokedAt != nil {
+		return nil, http.StatusUnauthorized, errors.New("machine client has been revoked")
+	}
+
+	sealedKeys := []string{client.SigningKey}
+	if client.PreviousSigningKey != "" && client.PreviousSecretExpiresAt != nil && time.Now().Before(*client.PreviousSecretExpiresAt) {
+		sealedKeys = append(sealedKeys, client.PreviousSigningKey)
+	}
+
+	valid := false
+	for _, sealed := range sealedKeys {
+		key, err := utility.DecryptString(sealed)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to read signing key: %v", err)
+		}
+		expected := MachineRequestSignature(key, method, requestURI, timestamp, nonce, body)
+		if hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, http.StatusUnauthorized, ErrInvalidSignature
+	}
+
+	record := models.MachineRequestNonce{
+		KeyID:     client.KeyID,
+		Nonce:     nonce,
+		ExpiresAt: sentAt.Add(maxSkew),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, http.StatusInternalServerError, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, http.StatusUnauthorized, errors.New("request nonce has already been used")
+	}
+
+	now := time.Now()
+	db.Model(&client).UpdateColumn("last_used_at", now)
+	client.LastUsedAt = &now
+
+	return &client, http.StatusOK, nil
+}
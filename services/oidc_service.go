@@ -0,0 +1,689 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OIDCProviderConfig configures one OpenID Connect identity provider. The
+// providers are read as a JSON array from OIDC_PROVIDERS.
+type OIDCProviderConfig struct {
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"display_name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
+	// GroupsClaim names the ID token claim listing the user's groups.
+	GroupsClaim string `json:"groups_claim"`
+	// RoleMapping maps an IdP group onto a SurvielX role. When a user is in
+	// several mapped groups the most privileged role wins.
+	RoleMapping map[string]string `json:"role_mapping"`
+	DefaultRole string            `json:"default_role"`
+	// SyncRoles re-applies the mapping on every login instead of only when
+	// the account is created or linked.
+	SyncRoles bool `json:"sync_roles"`
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type oidcProvider struct {
+	config OIDCProviderConfig
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]any
+	keysFetchedAt time.Time
+}
+
+var (
+	oidcProviders  = map[string]*oidcProvider{}
+	oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+	oidcRoleRank = map[string]int{models.RoleUser: 1, models.RoleSecurity: 2, models.RoleAdmin: 3}
+)
+
+func oidcStateTTL() time.Duration {
+	return utility.GetEnvDuration("OIDC_STATE_TTL", 10*time.Minute)
+}
+
+// InitOIDCProviders loads the identity providers configured in OIDC_PROVIDERS.
+func InitOIDCProviders() error {
+	raw := os.Getenv("OIDC_PROVIDERS")
+	if raw == "" {
+		return nil
+	}
+
+	var configs []OIDCProviderConfig
+	if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+		return fmt.Errorf("invalid OIDC_PROVIDERS: %v", err)
+	}
+
+	providers := make(map[string]*oidcProvider, len(configs))
+	for _, config := range configs {
+		if config.Name == "" || config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+			return errors.New("every OIDC provider needs a name, issuer, client_id and redirect_url")
+		}
+		if _, ok := providers[config.Name]; ok {
+			return fmt.Errorf("duplicate OIDC provider %q", config.Name)
+		}
+
+		if config.DisplayName == "" {
+			config.DisplayName = config.Name
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"openid", "email", "profile"}
+		}
+		if config.GroupsClaim == "" {
+			config.GroupsClaim = "groups"
+		}
+		if config.DefaultRole == "" {
+			config.DefaultRole = models.RoleUser
+		}
+		for _, role := range append([]string{config.DefaultRole}, mapValues(config.RoleMapping)...) {
+			if _, ok := oidcRoleRank[role]; !ok {
+				return fmt.Errorf("OIDC provider %q maps to unknown role %q", config.Name, role)
+			}
+		}
+
+		providers[config.Name] = &oidcProvider{config: config}
+	}
+
+	oidcProviders = providers
+	log.Printf("Loaded %d OIDC provider(s)", len(providers))
+	return nil
+}
+
+func GetOIDCProviders() []models.OIDCProviderResponse {
+	providers := make([]models.OIDCProviderResponse, 0, len(oidcProviders))
+	for _, p := range oidcProviders {
+		providers = append(providers, models.OIDCProviderResponse{Name: p.config.Name, DisplayName: p.config.DisplayName})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+// StartOIDCLogin begins an authorization-code flow with PKCE and returns the
+// provider URL the user has to visit.
+func StartOIDCLogin(db *gorm.DB, providerName string) (*models.OIDCAuthorizationResponse, int, error) {
+	provider, ok := oidcProviders[providerName]
+	if !ok {
+		return nil, http.StatusNotFound, errors.New("unknown identity provider")
+	}
+
+	discovery, err := provider.discover()
+	if err != nil {
+		log.Println("OIDC discovery failed:", err)
+		return nil, http.StatusBadGateway, errors.New("identity provider is unavailable")
+	}
+
+	state, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create state")
+	}
+	verifier, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create code verifier")
+	}
+	nonce, err := utility.GenerateSecureToken(16)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create nonce")
+	}
+
+	record := models.OIDCAuthState{
+		StateHash:    utility.HashToken(state),
+		Provider:     provider.config.Name,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcStateTTL()),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save login state: %v", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.config.ClientID},
+		"redirect_uri":          {provider.config.RedirectURL},
+		"scope":                 {strings.Join(provider.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return &models.OIDCAuthorizationResponse{
+		AuthorizationURL: discovery.AuthorizationEndpoint + separator + params.Encode(),
+		State:            state,
+	}, http.StatusOK, nil
+}
+
+// CompleteOIDCLogin exchanges the authorization code, verifies the ID token
+// and signs in the matching user, creating or linking the account if needed.
+func CompleteOIDCLogin(db *gorm.DB, providerName string, input models.OIDCCallbackInput, client models.ClientInfo) (*models.User, int, error) {
+	provider, ok := oidcProviders[providerName]
+	if !ok {
+		return nil, http.StatusNotFound, errors.New("unknown identity provider")
+	}
+
+	var state models.OIDCAuthState
+	result := db.Clauses(clause.Returning{}).
+		Where("state_hash = ? AND provider = ?", utility.HashToken(input.State), provider.config.Name).
+		Delete(&state)
+	if result.Error != nil {
+		return nil, http.StatusInternalServerError, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(state.ExpiresAt) {
+		return nil, http.StatusBadRequest, errors.New("login state is invalid or has expired")
+	}
+
+	tokens, err := provider.exchangeCode(input.Code, state.CodeVerifier)
+	if err != nil {
+		log.Println("OIDC code exchange failed:", err)
+		return nil, http.StatusUnauthorized, errors.New("identity provider rejected the login")
+	}
+
+	claims, err := provider.verifyIDToken(tokens.IDToken, state.Nonce)
+	if err != nil {
+		log.Println("OIDC ID token verification failed:", err)
+		return nil, http.StatusUnauthorized, errors.New("identity provider returned an invalid ID token")
+	}
+
+	user, code, err := resolveOIDCUser(db, provider, claims)
+	if err != nil {
+		return nil, code, err
+	}
+
+	if user.IsSuspended() {
+		return nil, http.StatusForbidden, ErrAccountSuspended
+	}
+
+	if user.IsTwoFactorEnabled() {
+		challenge, err := generateTwoFactorChallenge(user)
+		if err != nil {
+			return nil, http.StatusInternalServerError, errors.New("failed to create two-factor challenge")
+		}
+		user.TwoFactorRequired = true
+		user.TwoFactorChallenge = challenge
+		return user, http.StatusOK, nil
+	}
+
+	if code, err := startSession(db, user, client); err != nil {
+		return nil, code, err
+	}
+
+	return user, http.StatusOK, nil
+}
+
+// resolveOIDCUser finds the user linked to the IdP subject. Unlinked subjects
+// are linked to an existing account with the same, IdP-verified, email or get
+// a new account.
+func resolveOIDCUser(db *gorm.DB, provider *oidcProvider, claims jwt.MapClaims) (*models.User, int, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, http.StatusUnauthorized, errors.New("ID token has no subject")
+	}
+
+	email, _ := claims["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	emailVerified := claimBool(claims["email_verified"])
+	role, mapped := provider.mapRole(claims)
+	now := time.Now()
+
+	var (
+		user     models.User
+		identity models.UserIdentity
+	)
+
+	err := db.Where("provider = ? AND subject = ?", provider.config.Name, subject).First(&identity).Error
+	if err == nil {
+		if err := db.Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+			return nil, http.StatusUnauthorized, errors.New("linked account no longer exists")
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&identity).Update("last_login_at", now).Error; err != nil {
+				return err
+			}
+			if provider.config.SyncRoles && mapped && user.Role != role {
+				return applyOIDCRole(tx, &user, role)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to update linked account: %v", err)
+		}
+		return &user, http.StatusOK, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if email == "" {
+		return nil, http.StatusBadRequest, errors.New("identity provider did not share an email address")
+	}
+
+	identity = models.UserIdentity{
+		Provider:    provider.config.Name,
+		Subject:     subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+
+	err = db.Where("LOWER(email) = ?", email).First(&user).Error
+	if err == nil {
+		// only an address the IdP has verified proves the account is theirs
+		if !emailVerified {
+			return nil, http.StatusConflict, errors.New("an account with this email already exists, verify the email with your identity provider to link it")
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			identity.UserID = user.ID
+			if err := tx.Create(&identity).Error; err != nil {
+				return err
+			}
+			if user.EmailVerifiedAt == nil {
+				if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+					return err
+				}
+				user.EmailVerifiedAt = &now
+			}
+			if provider.config.SyncRoles && mapped && user.Role != role {
+				return applyOIDCRole(tx, &user, role)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to link account: %v", err)
+		}
+		return &user, http.StatusOK, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// the account can only be used through the IdP until a password is reset
+	unusablePassword, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create account")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(unusablePassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create account")
+	}
+
+	user = models.User{
+		Name:     oidcDisplayName(claims, email),
+		Email:    email,
+		Password: string(hashedPassword),
+		Role:     role,
+	}
+	if emailVerified {
+		user.EmailVerifiedAt = &now
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := user.CreateUser(tx); err != nil {
+			return fmt.Errorf("failed to create user: %v", err)
+		}
+
+		profile := models.Profile{UserID: user.ID, FullName: user.Name}
+		if err := profile.CreateProfile(tx); err != nil {
+			return fmt.Errorf("failed to create user profile: %v", err)
+		}
+
+		if user.Role == models.RoleSecurity {
+			if err := tx.Create(&models.Security{UserID: user.ID}).Error; err != nil {
+				return fmt.Errorf("failed to create security record: %v", err)
+			}
+		}
+
+		identity.UserID = user.ID
+		if err := tx.Create(&identity).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.UserActor(user.ID), AuditUserRegistered, AuditTargetUser, user.ID, nil, user)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	return &user, http.StatusCreated, nil
+}
+
+func applyOIDCRole(tx *gorm.DB, user *models.User, role string) error {
+	before := *user
+	if err := tx.Model(user).Update("role", role).Error; err != nil {
+		return err
+	}
+	if role == models.RoleSecurity && !models.CheckExists(tx, &models.Security{}, "user_id = ?", user.ID) {
+		if err := tx.Create(&models.Security{UserID: user.ID}).Error; err != nil {
+			return err
+		}
+	}
+	user.Role = role
+	// the identity provider decided the change, not a person in this system
+	return recordAudit(tx, models.SystemActor(), AuditUserRoleChanged, AuditTargetUser, user.ID, before, *user)
+}
+
+// mapRole returns the most privileged role mapped from the user's groups and
+// whether any group matched.
+func (p *oidcProvider) mapRole(claims jwt.MapClaims) (string, bool) {
+	var groups []string
+	switch v := claims[p.config.GroupsClaim].(type) {
+	case []any:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	case string:
+		groups = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	best, mapped := "", false
+	for _, group := range groups {
+		role, ok := p.config.RoleMapping[group]
+		if !ok {
+			continue
+		}
+		if !mapped || oidcRoleRank[role] > oidcRoleRank[best] {
+			best, mapped = role, true
+		}
+	}
+
+	if !mapped {
+		return p.config.DefaultRole, false
+	}
+	return best, true
+}
+
+func (p *oidcProvider) discover() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	issuer := strings.TrimSuffix(p.config.Issuer, "/")
+	if err := fetchJSON(issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *oidcProvider) exchangeCode(code, verifier string) (*oidcTokenResponse, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	resp, err := oidcHTTPClient.PostForm(discovery.TokenEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tokens oidcTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("invalid token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return &tokens, nil
+}
+
+func (p *oidcProvider) verifyIDToken(rawIDToken, nonce string) (jwt.MapClaims, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, p.keyfunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	return claims, nil
+}
+
+func (p *oidcProvider) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.lookupKey(kid)
+	// refetch on unknown kid, as the IdP may have rotated, but not more than
+	// once a minute
+	if !ok && time.Since(p.keysFetchedAt) > time.Minute {
+		if err := p.fetchKeys(); err != nil {
+			return nil, err
+		}
+		key, ok = p.lookupKey(kid)
+	}
+	if !ok {
+		return nil, errUnknownSigningKey
+	}
+	return key, nil
+}
+
+func (p *oidcProvider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys must be called with p.mu held.
+func (p *oidcProvider) fetchKeys() error {
+	if p.discovery == nil {
+		return errors.New("provider has not been discovered")
+	}
+
+	var jwks struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	if err := fetchJSON(p.discovery.JWKSURI, &jwks); err != nil {
+		return err
+	}
+
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		key, err := parseJWK(jwk)
+		if err != nil {
+			log.Printf("Skipping OIDC key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	return nil
+}
+
+func parseJWK(jwk oidcJWK) (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func fetchJSON(endpoint string, v any) error {
+	resp, err := oidcHTTPClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func oidcDisplayName(claims jwt.MapClaims, email string) string {
+	for _, claim := range []string{"name", "preferred_username"} {
+		if name, _ := claims[claim].(string); name != "" {
+			return name
+		}
+	}
+	return strings.Split(email, "@")[0]
+}
+
+// claimBool reads a boolean claim that some providers send as a string.
+func claimBool(v any) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
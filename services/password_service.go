@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"survielx-backend/connections"
+	"survielx-backend/mailer"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func passwordResetTTL() time.Duration {
+	return utility.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+}
+
+// ChangePassword updates the password of an authenticated user after checking
+// the current one, then signs them out everywhere.
+func ChangePassword(db *gorm.DB, actor models.AuditActor, userID string, input models.ChangePasswordInput) (int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return http.StatusNotFound, errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		return http.StatusUnauthorized, errors.New("current password is incorrect")
+	}
+
+	if input.CurrentPassword == input.NewPassword {
+		return http.StatusBadRequest, errors.New("new password must be different from the current password")
+	}
+
+	if code, err := setPassword(db, actor, AuditUserPasswordChanged, &user, input.NewPassword); err != nil {
+		return code, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// RequestPasswordReset emails a reset link when the address belongs to a user.
+// Unknown addresses are not reported so the endpoint cannot be used to
+// discover accounts.
+func RequestPasswordReset(db *gorm.DB, email string) (int, error) {
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusOK, nil
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	return sendPasswordResetEmail(db, &user)
+}
+
+func sendPasswordResetEmail(db *gorm.DB, user *models.User) (int, error) {
+	ttl := passwordResetTTL()
+	token, err := issueUserToken(db, user.ID, models.UserTokenPurposePasswordReset, ttl)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to create reset token: %v", err)
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nWe received a request to reset your SurvielX password.\n"+
+		"Use the link below within %s to choose a new password:\n\n%s\n\n"+
+		"If you did not request this, you can ignore this email.",
+		user.Name, ttl, buildTokenLink(os.Getenv("PASSWORD_RESET_URL"), token))
+
+	err = mailer.Send(mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your SurvielX password",
+		Body:    body,
+	})
+	if err != nil {
+		log.Println("Failed to send password reset email:", err)
+		return http.StatusInternalServerError, errors.New("failed to send password reset email")
+	}
+
+	return http.StatusOK, nil
+}
+
+// ResetPassword sets a new password using a token from RequestPasswordReset.
+func ResetPassword(db *gorm.DB, actor models.AuditActor, input models.ResetPasswordInput) (int, error) {
+	token, err := consumeUserToken(db, input.Token, models.UserTokenPurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, ErrInvalidUserToken) {
+			return http.StatusBadRequest, err
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", token.UserID).First(&user).Error; err != nil {
+		return http.StatusNotFound, errors.New("user not found")
+	}
+
+	// holding the token is what identifies the caller
+	actor.Type, actor.ID = models.AuditActorUser, user.ID
+	if code, err := setPassword(db, actor, AuditUserPasswordReset, &user, input.NewPassword); err != nil {
+		return code, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// setPassword stores the new password, signs the user out everywhere and
+// records the change under the given audit action.
+func setPassword(db *gorm.DB, actor models.AuditActor, action string, user *models.User, password string) (int, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return http.StatusBadRequest, errors.New("failed to hash password")
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		if err := RevokeAllUserTokens(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, action, AuditTargetUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to update password: %v", err)
+	}
+
+	connections.CloseUser(user.ID)
+
+	return http.StatusOK, nil
+}
+
+// buildTokenLink appends the token to a frontend URL, or returns the bare
+// token when no URL is configured.
+func buildTokenLink(baseURL, token string) string {
+	if baseURL == "" {
+		return token
+	}
+
+	separator := "?"
+	if strings.Contains(baseURL, "?") {
+		separator = "&"
+	}
+	return baseURL + separator + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"survielx-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// RoleHasPermissions reports whether the role has been granted every one of
+// the given permissions.
+func RoleHasPermissions(db *gorm.DB, role string, permissions ...string) (bool, error) {
+	if len(permissions) == 0 {
+		return true, nil
+	}
+
+	var count int64
+	err := db.Model(&models.RolePermission{}).
+		Where("role = ? AND permission_name IN ?", role, permissions).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return int(count) == len(uniqueStrings(permissions)), nil
+}
+
+func GetPermissions(db *gorm.DB) ([]models.Permission, int, error) {
+	var permissions []models.Permission
+	if err := db.Order("name").Find(&permissions).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch permissions: %v", err)
+	}
+	return permissions, http.StatusOK, nil
+}
+
+func GetRolePermissions(db *gorm.DB) ([]models.RolePermissionsResponse, int, error) {
+	var mappings []models.RolePermission
+	if err := db.Order("role, permission_name").Find(&mappings).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch role permissions: %v", err)
+	}
+
+	byRole := make(map[string][]string, len(models.Roles))
+	for _, role := range models.Roles {
+		byRole[role] = []string{}
+	}
+	for _, m := range mappings {
+		byRole[m.Role] = append(byRole[m.Role], m.PermissionName)
+	}
+
+	responses := make([]models.RolePermissionsResponse, 0, len(byRole))
+	for role, permissions := range byRole {
+		responses = append(responses, models.RolePermissionsResponse{Role: role, Permissions: permissions})
+	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].Role < responses[j].Role })
+
+	return responses, http.StatusOK, nil
+}
+
+// SetRolePermissions replaces the permissions granted to a role.
+func SetRolePermissions(db *gorm.DB, actor models.AuditActor, role string, permissions []string) (*models.RolePermissionsResponse, int, error) {
+	if !slices.Contains(models.Roles, role) {
+		return nil, http.StatusNotFound, fmt.Errorf("unknown role %s", role)
+	}
+
+	permissions = uniqueStrings(permissions)
+
+	var known int64
+	if err := db.Model(&models.Permission{}).Where("name IN ?", permissions).Count(&known).Error; err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if int(known) != len(permissions) {
+		return nil, http.StatusBadRequest, errors.New("one or more permissions do not exist")
+	}
+
+	if role == models.RoleAdmin && !slices.Contains(permissions, models.PermissionRolesManage) {
+		// without this admins could lock everyone out of the policy
+		return nil, http.StatusBadRequest, fmt.Errorf("the admin role must keep the %s permission", models.PermissionRolesManage)
+	}
+
+	var previous []string
+	if err := db.Model(&models.RolePermission{}).Where("role = ?", role).Order("permission_name").Pluck("permission_name", &previous).Error; err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	sort.Strings(permissions)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		for _, p := range permissions {
+			if err := tx.Create(&models.RolePermission{Role: role, PermissionName: p}).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, actor, AuditRolePermissionsSet, AuditTargetRole, role,
+			models.RolePermissionsResponse{Role: role, Permissions: previous},
+			models.RolePermissionsResponse{Role: role, Permissions: permissions})
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update role permissions: %v", err)
+	}
+
+	return &models.RolePermissionsResponse{Role: role, Permissions: permissions}, http.StatusOK, nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
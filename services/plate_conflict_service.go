@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"survielx-backend/models"
+
+	"gorm.io/gorm"
+)
+
+func GetPlateConflicts(db *gorm.DB, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		conflicts []models.PlateConflict
+		count     int64
+	)
+
+	query := db.Model(&models.PlateConflict{})
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count plate conflicts: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("created_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&conflicts).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch plate conflicts: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: conflicts,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(conflicts),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
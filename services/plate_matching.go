@@ -0,0 +1,191 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PlateMatchExact = "exact"
+	PlateMatchFuzzy = "fuzzy"
+	PlateMatchNone  = "none"
+)
+
+// ocrConfusionGroup maps each character in utility.PlateConfusions to its
+// group. Swapping characters within a group costs less than any other edit.
+var ocrConfusionGroup = func() map[rune]int {
+	groups := make(map[rune]int)
+	for i, group := range utility.PlateConfusions {
+		for _, r := range group {
+			groups[r] = i + 1
+		}
+	}
+	return groups
+}()
+
+const ocrConfusionCost = 0.3
+
+func plateFuzzyMinScore() float64 {
+	return utility.GetEnvFloat("PLATE_FUZZY_MIN_SCORE", 0.6)
+}
+
+// plateFuzzyAutoAccept is the score at which the best candidate is taken as
+// the vehicle without a person confirming it.
+func plateFuzzyAutoAccept() float64 {
+	return utility.GetEnvFloat("PLATE_FUZZY_AUTO_ACCEPT", 0.9)
+}
+
+// plateTrigramThreshold is the trigram similarity between skeletons below
+// which a plate is not considered at all. Each character that differs
+// outright, rather than by a known confusion, removes up to three trigrams.
+func plateTrigramThreshold() float64 {
+	return utility.GetEnvFloat("PLATE_TRIGRAM_THRESHOLD", 0.3)
+}
+
+// plateTrigramCandidateLimit caps the plates scored by edit distance.
+const plateTrigramCandidateLimit = 100
+
+func substitutionCost(a, b rune) float64 {
+	if a == b {
+		return 0
+	}
+	if g := ocrConfusionGroup[a]; g != 0 && g == ocrConfusionGroup[b] {
+		return ocrConfusionCost
+	}
+	return 1
+}
+
+// plateDistance is the Levenshtein distance between two normalized plates
+// with OCR confusions weighted down.
+func plateDistance(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]float64, len(rb)+1)
+	curr := make([]float64, len(rb)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = float64(i)
+		for j := 1; j <= len(rb); j++ {
+			curr[j] = min(
+				prev[j]+1,
+				curr[j-1]+1,
+				prev[j-1]+substitutionCost(ra[i-1], rb[j-1]),
+			)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// plateSimilarity scores two normalized plates from 0 (unrelated) to 1 (equal).
+func plateSimilarity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 0
+	}
+	return max(0, 1-plateDistance(a, b)/float64(longest))
+}
+
+// MatchVehicles returns approved vehicles whose plate may be the one read,
+// best first. An exact match is returned on its own.
+func MatchVehicles(db *gorm.DB, plateNumber string, limit int) ([]models.VehicleCandidate, error) {
+	normalized := utility.NormalizePlateNumber(plateNumber)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	var exact models.Vehicle
+	err := db.Where("normalized_plate = ? AND status = ?", normalized, models.VehicleStatusApproved).Limit(1).Find(&exact).Error
+	if err != nil {
+		return nil, err
+	}
+	if exact.ID != "" {
+		return []models.VehicleCandidate{{
+			VehicleID:   exact.ID,
+			PlateNumber: exact.PlateNumber,
+			Score:       1,
+			MatchType:   PlateMatchExact,
+		}}, nil
+	}
+
+	// the trigram index narrows the plates down to those sharing most of the
+	// read's skeleton, so misreads cost nothing here and only the candidates
+	// are scored by edit distance
+	skeleton := utility.PlateSkeleton(normalized)
+	skeletonSQL := utility.PlateSkeletonSQL("normalized_plate")
+	var vehicles []models.Vehicle
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// the % operator, unlike similarity(), can use the index but only
+		// takes its threshold from this setting
+		threshold := strconv.FormatFloat(plateTrigramThreshold(), 'f', -1, 64)
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", threshold).Error; err != nil {
+			return err
+		}
+
+		// a misread rarely adds or drops more than one character
+		return tx.Select("id", "plate_number", "normalized_plate").
+			Where(skeletonSQL+" % ?", skeleton).
+			Where("LENGTH(normalized_plate) BETWEEN ? AND ?", len(normalized)-1, len(normalized)+1).
+			Where("status = ?", models.VehicleStatusApproved).
+			Order(clause.Expr{SQL: "similarity(" + skeletonSQL + ", ?) DESC", Vars: []any{skeleton}}).
+			Limit(plateTrigramCandidateLimit).
+			Find(&vehicles).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rankPlateCandidates(normalized, vehicles, plateFuzzyMinScore(), limit), nil
+}
+
+// rankPlateCandidates scores the vehicles against the plate read and returns
+// those scoring at least minScore, best first.
+func rankPlateCandidates(normalized string, vehicles []models.Vehicle, minScore float64, limit int) []models.VehicleCandidate {
+	candidates := []models.VehicleCandidate{}
+	for _, v := range vehicles {
+		score := plateSimilarity(normalized, v.NormalizedPlate)
+		if score < minScore {
+			continue
+		}
+		candidates = append(candidates, models.VehicleCandidate{
+			VehicleID:   v.ID,
+			PlateNumber: v.PlateNumber,
+			Score:       score,
+			MatchType:   PlateMatchFuzzy,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// acceptedCandidate returns the candidate that can be used without
+// confirmation: an exact match, or a fuzzy match at or above the auto-accept
+// score that no other candidate ties.
+func acceptedCandidate(candidates []models.VehicleCandidate) *models.VehicleCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	best := candidates[0]
+	if best.MatchType == PlateMatchExact {
+		return &best
+	}
+	if best.Score < plateFuzzyAutoAccept() {
+		return nil
+	}
+	if len(candidates) > 1 && candidates[1].Score == best.Score {
+		return nil
+	}
+	return &best
+}
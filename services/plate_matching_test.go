@@ -0,0 +1,120 @@
+package services
+
+import (
+	"math"
+	"survielx-backend/models"
+	"testing"
+)
+
+func TestPlateDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"ABC123DE", "ABC123DE", 0},
+		{"ABC123DE", "A8C123DE", ocrConfusionCost},
+		{"ABC123DE", "A8C12JDE", ocrConfusionCost + 1},
+		{"ABC123DE", "ABX123DE", 1},
+		{"ABC123DE", "ABC123D", 1},
+		{"ABC123DE", "ABC1234DE", 1},
+		{"ABC123DE", "", 8},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := plateDistance(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("plateDistance = %v, want %v", got, tt.want)
+			}
+			if got := plateDistance(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("plateDistance reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlateSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"ABC123DE", "ABC123DE", 1},
+		{"ABC123DE", "A8C123DE", 1 - ocrConfusionCost/8},
+		{"ABC123DE", "ABX123DE", 1 - 1.0/8},
+		{"AB", "XY", 0},
+		{"A", "XYZ", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := plateSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("plateSimilarity = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankPlateCandidates(t *testing.T) {
+	vehicles := []models.Vehicle{
+		{ID: "far", PlateNumber: "XYZ987AB", NormalizedPlate: "XYZ987AB"},
+		{ID: "edit", PlateNumber: "ABX 123 DE", NormalizedPlate: "ABX123DE"},
+		{ID: "confused", PlateNumber: "ABC 123 DE", NormalizedPlate: "ABC123DE"},
+		{ID: "two-edits", PlateNumber: "ABX 12X DE", NormalizedPlate: "ABX12XDE"},
+	}
+
+	tests := []struct {
+		name     string
+		minScore float64
+		limit    int
+		want     []string
+	}{
+		{"best first", 0.6, 0, []string{"confused", "edit", "two-edits"}},
+		{"below minimum dropped", 0.8, 0, []string{"confused", "edit"}},
+		{"limited", 0.6, 1, []string{"confused"}},
+		{"none close enough", 0.99, 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := rankPlateCandidates("A8C123DE", vehicles, tt.minScore, tt.limit)
+			if len(candidates) != len(tt.want) {
+				t.Fatalf("got %d candidates, want %v", len(candidates), tt.want)
+			}
+			for i, c := range candidates {
+				if c.VehicleID != tt.want[i] || c.MatchType != PlateMatchFuzzy {
+					t.Errorf("candidate %d = %s (%s), want %s", i, c.VehicleID, c.MatchType, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAcceptedCandidate(t *testing.T) {
+	fuzzy := func(id string, score float64) models.VehicleCandidate {
+		return models.VehicleCandidate{VehicleID: id, Score: score, MatchType: PlateMatchFuzzy}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []models.VehicleCandidate
+		want       string
+	}{
+		{"no candidates", nil, ""},
+		{"exact", []models.VehicleCandidate{{VehicleID: "a", Score: 1, MatchType: PlateMatchExact}}, "a"},
+		{"confident fuzzy", []models.VehicleCandidate{fuzzy("a", 0.95), fuzzy("b", 0.7)}, "a"},
+		{"weak fuzzy", []models.VehicleCandidate{fuzzy("a", 0.8)}, ""},
+		{"tied fuzzy", []models.VehicleCandidate{fuzzy("a", 0.95), fuzzy("b", 0.95)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := acceptedCandidate(tt.candidates)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("accepted %s, want none", got.VehicleID)
+				}
+				return
+			}
+			if got == nil || got.VehicleID != tt.want {
+				t.Errorf("accepted %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
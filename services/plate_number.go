@@ -1,92 +1,45 @@
 package services
 
 import (
-	"regexp"
+	"fmt"
+	"os"
 	"strings"
+	"survielx-backend/plates"
 )
 
-var nigerianStates = map[string]bool{
-	"AB": true, // Abia
-	"AD": true, // Adamawa
-	"AK": true, // Akwa Ibom
-	"AN": true, // Anambra
-	"BA": true, // Bauchi
-	"BY": true, // Bayelsa
-	"BE": true, // Benue
-	"BO": true, // Borno
-	"CR": true, // Cross River
-	"DE": true, // Delta
-	"EB": true, // Ebonyi
-	"ED": true, // Edo
-	"EK": true, // Ekiti
-	"EN": true, // Enugu
-	"GO": true, // Gombe
-	"IM": true, // Imo
-	"JI": true, // Jigawa
-	"KD": true, // Kaduna
-	"KN": true, // Kano
-	"KT": true, // Katsina
-	"KE": true, // Kebbi
-	"KO": true, // Kogi
-	"KW": true, // Kwara
-	"LA": true, // Lagos
-	"NA": true, // Nasarawa
-	"NI": true, // Niger
-	"OG": true, // Ogun
-	"ON": true, // Ondo
-	"OS": true, // Osun
-	"OY": true, // Oyo
-	"PL": true, // Plateau
-	"RI": true, // Rivers
-	"SO": true, // Sokoto
-	"TA": true, // Taraba
-	"YO": true, // Yobe
-	"ZA": true, // Zamfara
-	"FC": true, // Federal Capital Territory (Abuja)
-}
-
-func IsValidNigerianPlate(plateNumber string) bool {
-	plateNumber = strings.ToUpper(strings.TrimSpace(plateNumber))
-	plateNumber = strings.ReplaceAll(plateNumber, " ", "")
-	plateNumber = strings.ReplaceAll(plateNumber, "-", "")
+// acceptedPlateCountries are tried in order when parsing a plate number.
+var acceptedPlateCountries = []string{"NG"}
 
-	if len(plateNumber) == 0 {
-		return false
+// InitPlateCountries reads the countries whose plates this site accepts from
+// ACCEPTED_PLATE_COUNTRIES, a comma separated list of country codes such as
+// "NG,BJ". Nigeria alone is accepted when it is unset.
+func InitPlateCountries() error {
+	raw := os.Getenv("ACCEPTED_PLATE_COUNTRIES")
+	if raw == "" {
+		return nil
 	}
 
-	if regexp.MustCompile(`^[A-Z]{2}[0-9]{3}[A-Z]{3}$`).MatchString(plateNumber) {
-		stateCode := plateNumber[:2]
-		if nigerianStates[stateCode] {
-			return true
+	var countries []string
+	for _, country := range strings.Split(raw, ",") {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if country == "" {
+			continue
 		}
-	}
-
-	if regexp.MustCompile(`^[A-Z]{3}[0-9]{3}[A-Z]{2}$`).MatchString(plateNumber) {
-		stateCode := plateNumber[5:7]
-		if nigerianStates[stateCode] {
-			return true
+		if _, ok := plates.Lookup(country); !ok {
+			return fmt.Errorf("no plate validator for country %s, known countries are %s",
+				country, strings.Join(plates.Countries(), ", "))
 		}
+		countries = append(countries, country)
 	}
-
-	if regexp.MustCompile(`^[A-Z]{3}[0-9]{3}[A-Z]{1}$`).MatchString(plateNumber) {
-		return true
-	}
-
-	if regexp.MustCompile(`^[A-Z]{3}[0-9]{3}[A-Z]{2,3}$`).MatchString(plateNumber) {
-		return true
+	if len(countries) == 0 {
+		return fmt.Errorf("ACCEPTED_PLATE_COUNTRIES lists no countries")
 	}
 
-	if regexp.MustCompile(`^CD[0-9]{3}[A-Z]{1}$`).MatchString(plateNumber) {
-		return true
-	}
-
-	if regexp.MustCompile(`^(NA|AF|NN)[0-9]{3,4}[A-Z]?$`).MatchString(plateNumber) {
-		return true
-	}
-
-	if regexp.MustCompile(`^[A-Z]{2,4}[0-9]{2,4}[A-Z]{1,3}$`).MatchString(plateNumber) {
-		return true
-	}
+	acceptedPlateCountries = countries
+	return nil
+}
 
-	return false
+// ParsePlateNumber validates a plate number against the accepted countries.
+func ParsePlateNumber(plateNumber string) (*plates.Plate, error) {
+	return plates.Parse(plateNumber, acceptedPlateCountries)
 }
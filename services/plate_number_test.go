@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"survielx-backend/plates"
+	"testing"
+)
+
+func TestParsePlateNumber(t *testing.T) {
+	defer func(countries []string) { acceptedPlateCountries = countries }(acceptedPlateCountries)
+
+	tests := []struct {
+		name      string
+		countries []string
+		input     string
+		want      *plates.Plate
+		invalid   bool
+	}{
+		{
+			name:      "nigerian standard",
+			countries: []string{"NG"},
+			input:     "abc-123 de",
+			want:      &plates.Plate{Country: "NG", Region: "ABC", Serial: "123DE", Category: plates.CategoryStandard, Normalized: "ABC123DE"},
+		},
+		{
+			name:      "nigerian legacy",
+			countries: []string{"NG"},
+			input:     "LA 123 ABC",
+			want:      &plates.Plate{Country: "NG", Region: "LA", RegionName: "Lagos", Serial: "123ABC", Category: plates.CategoryLegacy, Normalized: "LA123ABC"},
+		},
+		{
+			name:      "nigerian legacy with unknown state",
+			countries: []string{"NG"},
+			input:     "QQ 123 ABC",
+			invalid:   true,
+		},
+		{
+			name:      "nigerian military",
+			countries: []string{"NG"},
+			input:     "NA 1234 A",
+			want:      &plates.Plate{Country: "NG", Region: "NA", RegionName: "Nigerian Army", Serial: "1234A", Category: plates.CategoryMilitary, Normalized: "NA1234A"},
+		},
+		{
+			name:      "nigerian diplomatic",
+			countries: []string{"NG"},
+			input:     "CD 123 A",
+			want:      &plates.Plate{Country: "NG", Serial: "123A", Category: plates.CategoryDiplomatic, Normalized: "CD123A"},
+		},
+		{
+			name:      "ghanaian plate rejected when only Nigeria is accepted",
+			countries: []string{"NG"},
+			input:     "GR 1234-20",
+			invalid:   true,
+		},
+		{
+			name:      "ghanaian standard",
+			countries: []string{"NG", "GH"},
+			input:     "GR 1234-20",
+			want:      &plates.Plate{Country: "GH", Region: "GR", RegionName: "Greater Accra", Serial: "123420", Category: plates.CategoryStandard, Normalized: "GR123420"},
+		},
+		{
+			name:      "beninese standard",
+			countries: []string{"NG", "BJ"},
+			input:     "AB 1234 RB",
+			want:      &plates.Plate{Country: "BJ", Serial: "AB1234", Category: plates.CategoryStandard, Normalized: "AB1234RB"},
+		},
+		{
+			name:      "first accepted country wins",
+			countries: []string{"GH", "NG"},
+			input:     "CD 123",
+			want:      &plates.Plate{Country: "GH", Region: "CD", Serial: "123", Category: plates.CategoryDiplomatic, Normalized: "CD123"},
+		},
+		{
+			name:      "punctuation only",
+			countries: []string{"NG"},
+			input:     " - ",
+			invalid:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acceptedPlateCountries = tt.countries
+
+			plate, err := ParsePlateNumber(tt.input)
+			if tt.invalid {
+				if !errors.Is(err, plates.ErrInvalidPlate) {
+					t.Fatalf("err = %v, want ErrInvalidPlate", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *plate != *tt.want {
+				t.Errorf("plate = %+v, want %+v", *plate, *tt.want)
+			}
+		})
+	}
+}
+
+func TestInitPlateCountries(t *testing.T) {
+	defer func(countries []string) { acceptedPlateCountries = countries }(acceptedPlateCountries)
+
+	tests := []struct {
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{env: " ng , bj ", want: []string{"NG", "BJ"}},
+		{env: "NG,,GH", want: []string{"NG", "GH"}},
+		{env: "NG,XX", wantErr: true},
+		{env: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			acceptedPlateCountries = []string{"NG"}
+			t.Setenv("ACCEPTED_PLATE_COUNTRIES", tt.env)
+
+			err := InitPlateCountries()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !slices.Equal(acceptedPlateCountries, tt.want) {
+				t.Errorf("countries = %v, want %v", acceptedPlateCountries, tt.want)
+			}
+		})
+	}
+}
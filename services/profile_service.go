@@ -8,7 +8,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func UpdateUserProfile(db *gorm.DB, userID string, req *models.UpdateUserProfileInput) (int, error) {
+func UpdateUserProfile(db *gorm.DB, actor models.AuditActor, userID string, req *models.UpdateUserProfileInput) (int, error) {
 	var (
 		user    models.User
 		profile models.Profile
@@ -30,13 +30,26 @@ func UpdateUserProfile(db *gorm.DB, userID string, req *models.UpdateUserProfile
 		Phone:       req.Phone,
 	}
 
-	result, err := models.UpdateFields(db, &profile, profileUpdates, "user_id = ?", userID)
-	if err != nil {
-		return http.StatusBadRequest, err
-	}
+	code := http.StatusBadRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result, err := models.UpdateFields(tx, &profile, profileUpdates, "user_id = ?", userID)
+		if err != nil {
+			return err
+		}
 
-	if result.RowsAffected == 0 {
-		return http.StatusBadRequest, errors.New("no fields updated")
+		if result.RowsAffected == 0 {
+			return errors.New("no fields updated")
+		}
+
+		code = http.StatusInternalServerError
+		var after models.Profile
+		if err := tx.Where("user_id = ?", userID).First(&after).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditProfileUpdated, AuditTargetProfile, after.ID, profile, after)
+	})
+	if err != nil {
+		return code, err
 	}
 
 	return http.StatusOK, nil
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"survielx-backend/connections"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokeAccessToken adds the token's jti to the revocation list until it expires.
+func RevokeAccessToken(db *gorm.DB, jti, userID string, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("token has no jti")
+	}
+
+	entry := models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error
+}
+
+// RevokeAllUserTokens invalidates every access token issued to the user so far
+// and revokes all of their refresh tokens and sessions. It usually runs inside
+// a larger transaction, so callers close the user's WebSockets with
+// connections.CloseUser once that commits.
+func RevokeAllUserTokens(db *gorm.DB, userID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("tokens_revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// IsAccessTokenRevoked checks the token's jti against the revocation list, its
+// session, and its issue time against the user's logout-everywhere cutoff.
+func IsAccessTokenRevoked(db *gorm.DB, claims jwt.MapClaims) (bool, error) {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		// tokens issued before jti was introduced cannot be revoked individually
+		return true, nil
+	}
+
+	var count int64
+	if err := db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if sessionID, _ := claims["sid"].(string); sessionID != "" {
+		revoked, err := isSessionRevoked(db, sessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	userID, _ := claims["sub"].(string)
+	var user models.User
+	if err := db.Select("id", "tokens_revoked_at").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if user.TokensRevokedAt != nil {
+		issuedAt, err := claims.GetIssuedAt()
+		if err != nil || issuedAt == nil || issuedAt.Unix() < user.TokensRevokedAt.Unix() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Logout revokes the current access token and its session and, when supplied,
+// the refresh token family it was issued with.
+func Logout(db *gorm.DB, actor models.AuditActor, userID string, claims jwt.MapClaims, rawRefreshToken string) (int, error) {
+	jti, _ := claims["jti"].(string)
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return http.StatusBadRequest, errors.New("token has no expiry")
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := RevokeAccessToken(tx, jti, userID, expiresAt.Time); err != nil {
+			return err
+		}
+
+		if sessionID != "" {
+			if err := revokeSession(tx, sessionID); err != nil {
+				return err
+			}
+		}
+
+		if rawRefreshToken != "" {
+			var refreshToken models.RefreshToken
+			err := tx.Where("token_hash = ? AND user_id = ?", utility.HashToken(rawRefreshToken), userID).First(&refreshToken).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err := revokeTokenFamily(tx, refreshToken.FamilyID); err != nil {
+					return err
+				}
+			}
+		}
+
+		return recordAudit(tx, actor, AuditUserLoggedOut, AuditTargetUser, userID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if sessionID != "" {
+		connections.CloseSession(userID, sessionID)
+	}
+
+	return http.StatusOK, nil
+}
+
+// LogoutAll signs the user out of every device.
+func LogoutAll(db *gorm.DB, actor models.AuditActor, userID string, claims jwt.MapClaims) (int, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := RevokeAllUserTokens(tx, userID); err != nil {
+			return err
+		}
+
+		// the cutoff has one second granularity, so revoke the current token explicitly
+		jti, _ := claims["jti"].(string)
+		if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil && jti != "" {
+			if err := RevokeAccessToken(tx, jti, userID, expiresAt.Time); err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(tx, actor, AuditUserLoggedOutAll, AuditTargetUser, userID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	connections.CloseUser(userID)
+
+	return http.StatusOK, nil
+}
+
+// PurgeExpiredRevocations removes revocation entries, refresh tokens, machine
+// request nonces, OIDC login states and sessions that can no longer be used
+// because they have expired.
+func PurgeExpiredRevocations(db *gorm.DB) (int64, error) {
+	now := time.Now()
+
+	result := db.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	purged := result.RowsAffected
+
+	result = db.Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return purged, result.Error
+	}
+	purged += result.RowsAffected
+
+	result = db.Where("expires_at < ?", now).Delete(&models.MachineRequestNonce{})
+	if result.Error != nil {
+		return purged, result.Error
+	}
+	purged += result.RowsAffected
+
+	result = db.Where("expires_at < ?", now).Delete(&models.OIDCAuthState{})
+	if result.Error != nil {
+		return purged, result.Error
+	}
+	purged += result.RowsAffected
+
+	// a session idle for longer than a refresh token lives cannot be resumed
+	result = db.Where("last_seen_at < ?", now.Add(-refreshTokenTTL())).Delete(&models.Session{})
+	if result.Error != nil {
+		return purged, result.Error
+	}
+
+	return purged + result.RowsAffected, nil
+}
+
+// StartRevocationCleanup periodically purges expired revocation entries.
+func StartRevocationCleanup(db *gorm.DB) {
+	interval := utility.GetEnvDuration("REVOCATION_CLEANUP_INTERVAL", time.Hour)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := PurgeExpiredRevocations(db)
+			if err != nil {
+				log.Println("Failed to purge expired revocations:", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d expired revocation entries", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"survielx-backend/connections"
+	"survielx-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// last-seen times are only written once per interval to avoid a write on
+// every authenticated request
+const sessionTouchInterval = time.Minute
+
+// startSession records a new signed-in device for the user and issues the
+// session's first token pair.
+func startSession(db *gorm.DB, user *models.User, client models.ClientInfo) (int, error) {
+	if user.IsSuspended() {
+		return http.StatusForbidden, ErrAccountSuspended
+	}
+
+	label := strings.TrimSpace(client.DeviceLabel)
+	if label == "" {
+		label = deviceLabelFromUserAgent(client.UserAgent)
+	}
+
+	session := models.Session{
+		UserID:      user.ID,
+		DeviceLabel: label,
+		IPAddress:   client.IPAddress,
+		UserAgent:   client.UserAgent,
+		LastSeenAt:  time.Now(),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return http.StatusInternalServerError, errors.New("failed to create session")
+	}
+
+	if _, code, err := issueTokenPair(db, user, session.ID); err != nil {
+		return code, err
+	}
+	return http.StatusOK, nil
+}
+
+// TouchSession updates the session's last-seen time.
+func TouchSession(db *gorm.DB, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	now := time.Now()
+	db.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, now.Add(-sessionTouchInterval)).
+		UpdateColumn("last_seen_at", now)
+}
+
+// ConnectSession marks the session as holding a WebSocket open and returns
+// the time of the mark, which DisconnectSession needs to clear it.
+func ConnectSession(db *gorm.DB, sessionID string) (time.Time, error) {
+	// Postgres keeps microseconds, so the mark can be matched exactly later
+	now := time.Now().Truncate(time.Microsecond)
+	err := db.Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		UpdateColumns(map[string]any{"connected_at": now, "last_seen_at": now}).Error
+	return now, err
+}
+
+// DisconnectSession clears the mark ConnectSession set, unless a newer
+// connection of the session has replaced it.
+func DisconnectSession(db *gorm.DB, sessionID string, connectedAt time.Time) error {
+	return db.Model(&models.Session{}).
+		Where("id = ? AND connected_at = ?", sessionID, connectedAt).
+		UpdateColumn("connected_at", nil).Error
+}
+
+func isSessionRevoked(db *gorm.DB, sessionID string) (bool, error) {
+	var session models.Session
+	err := db.Select("id", "revoked_at").Where("id = ?", sessionID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return session.RevokedAt != nil, nil
+}
+
+func GetUserSessions(db *gorm.DB, userID, currentSessionID string) ([]models.Session, int, error) {
+	var sessions []models.Session
+	err := db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-refreshTokenTTL())).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch sessions: %v", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, http.StatusOK, nil
+}
+
+// RevokeSession signs one of the user's devices out. Its refresh tokens stop
+// working, its access tokens are rejected and its WebSocket is closed.
+func RevokeSession(db *gorm.DB, actor models.AuditActor, userID, sessionID string) (int, error) {
+	var session models.Session
+	if err := db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusNotFound, errors.New("session not found")
+		}
+		return http.StatusInternalServerError, err
+	}
+
+	if session.RevokedAt != nil {
+		return http.StatusBadRequest, errors.New("session is already revoked")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := revokeSession(tx, session.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditSessionRevoked, AuditTargetSession, session.ID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to revoke session: %v", err)
+	}
+
+	connections.CloseSession(session.UserID, session.ID)
+
+	return http.StatusOK, nil
+}
+
+// revokeSession revokes the session and its refresh tokens. Callers close its
+// WebSocket with connections.CloseSession once their transaction commits.
+func revokeSession(db *gorm.DB, sessionID string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return revokeTokenFamily(tx, sessionID)
+	})
+}
+
+func deviceLabelFromUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"Dart/", "Mobile app"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, p := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder collects the statements a dry run session would have executed.
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// dryRunDB builds statements without a database. Skipping the default
+// transaction keeps gorm from opening a connection.
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, recorder
+}
+
+func TestSessionConnectionTracking(t *testing.T) {
+	db, recorder := dryRunDB(t)
+
+	connectedAt, err := ConnectSession(db, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !connectedAt.Equal(connectedAt.Truncate(time.Microsecond)) {
+		t.Errorf("connectedAt %v keeps sub-microsecond precision", connectedAt)
+	}
+	if err := DisconnectSession(db, "session-1", connectedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"connect", []string{`UPDATE "sessions" SET`, `"connected_at"=`, `"last_seen_at"=`, `WHERE id = 'session-1'`}},
+		{"disconnect", []string{`UPDATE "sessions" SET "connected_at"=NULL`, `WHERE id = 'session-1' AND connected_at = `}},
+	}
+	if len(recorder.statements) != len(tests) {
+		t.Fatalf("statements = %q", recorder.statements)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, part := range tt.want {
+				if !strings.Contains(recorder.statements[i], part) {
+					t.Errorf("statement %q does not contain %q", recorder.statements[i], part)
+				}
+			}
+		})
+	}
+}
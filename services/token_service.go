@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const accessTokenType = "access"
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, all sessions in this family have been revoked")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+)
+
+func accessTokenTTL() time.Duration {
+	return utility.GetEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+func refreshTokenTTL() time.Duration {
+	return utility.GetEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// tokenIssuer and tokenAudience name this deployment in the tokens it issues,
+// so tokens from another deployment sharing keys are not accepted.
+func tokenIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "survielx-backend"
+}
+
+func tokenAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "survielx-api"
+}
+
+// GenerateAccessToken signs a short-lived access token for the user's session.
+func GenerateAccessToken(user *models.User, sessionID string) (string, error) {
+	claims := newTokenClaims(user.ID, accessTokenType, accessTokenTTL())
+	claims["sid"] = sessionID
+	return keyManager.Sign(claims)
+}
+
+// ParseAccessToken validates the signature, expiry and type of an access token
+// and returns its claims.
+func ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	return parseToken(tokenString, accessTokenType)
+}
+
+func newTokenClaims(subject, tokenType string, ttl time.Duration) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": tokenIssuer(),
+		"aud": tokenAudience(),
+		"sub": subject,
+		"jti": utility.GenerateUUID(),
+		"typ": tokenType,
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	}
+}
+
+func parseToken(tokenString, tokenType string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyManager.Keyfunc,
+		jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}),
+		jwt.WithIssuer(tokenIssuer()),
+		jwt.WithAudience(tokenAudience()),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// AuthenticateAccessToken parses an access token and rejects it when it has
+// been revoked through logout or its user is suspended.
+func AuthenticateAccessToken(db *gorm.DB, tokenString string) (jwt.MapClaims, error) {
+	claims, err := ParseAccessToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := IsAccessTokenRevoked(db, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	suspended, err := accountSuspended(db, claims["sub"].(string))
+	if err != nil {
+		return nil, err
+	}
+	if suspended {
+		return nil, ErrAccountSuspended
+	}
+
+	return claims, nil
+}
+
+// issueTokenPair creates an access token and a new refresh token in the given
+// family and attaches both to the user. The family is the session the tokens
+// belong to.
+func issueTokenPair(db *gorm.DB, user *models.User, familyID string) (*models.RefreshToken, int, error) {
+	accessToken, err := GenerateAccessToken(user, familyID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create token")
+	}
+
+	rawRefreshToken, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to create refresh token")
+	}
+
+	refreshToken := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utility.HashToken(rawRefreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL()),
+	}
+	if err := db.Create(&refreshToken).Error; err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to persist refresh token")
+	}
+
+	user.Token = accessToken
+	user.RefreshToken = rawRefreshToken
+	return &refreshToken, http.StatusOK, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new access/refresh token pair.
+// The presented token is revoked; presenting it again revokes its whole family.
+func RefreshTokens(db *gorm.DB, rawRefreshToken string) (*models.User, int, error) {
+	var (
+		current models.RefreshToken
+		user    models.User
+	)
+
+	err := db.Where("token_hash = ?", utility.HashToken(rawRefreshToken)).First(&current).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, http.StatusUnauthorized, ErrInvalidRefreshToken
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if current.RevokedAt != nil {
+		if err := revokeTokenFamily(db, current.FamilyID); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return nil, http.StatusUnauthorized, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, http.StatusUnauthorized, errors.New("refresh token has expired")
+	}
+
+	if err := db.Where("id = ?", current.UserID).First(&user).Error; err != nil {
+		return nil, http.StatusUnauthorized, ErrInvalidRefreshToken
+	}
+	if user.IsSuspended() {
+		return nil, http.StatusForbidden, ErrAccountSuspended
+	}
+
+	TouchSession(db, current.FamilyID)
+
+	code := http.StatusOK
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			code = http.StatusInternalServerError
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// another request rotated this token first
+			code = http.StatusUnauthorized
+			return ErrRefreshTokenReused
+		}
+
+		next, status, err := issueTokenPair(tx, &user, current.FamilyID)
+		if err != nil {
+			code = status
+			return err
+		}
+
+		return tx.Model(&models.RefreshToken{}).
+			Where("id = ?", current.ID).
+			Update("replaced_by_id", next.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if rerr := revokeTokenFamily(db, current.FamilyID); rerr != nil {
+				return nil, http.StatusInternalServerError, rerr
+			}
+		}
+		if code == http.StatusOK {
+			code = http.StatusInternalServerError
+		}
+		return nil, code, err
+	}
+
+	return &user, http.StatusOK, nil
+}
+
+func revokeTokenFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
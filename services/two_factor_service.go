@@ -0,0 +1,317 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengeType = "2fa_challenge"
+	recoveryCodeCount      = 10
+	// accept codes from one step either side to tolerate clock drift
+	totpSkew = 1
+)
+
+var errInvalidSecondFactor = errors.New("invalid two-factor code")
+
+func twoFactorIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "SurvielX"
+}
+
+// TwoFactorRequiredForRole reports whether users with the role must enroll in
+// two-factor authentication before using privileged endpoints. Roles are
+// configured through TWO_FACTOR_REQUIRED_ROLES (comma separated).
+func TwoFactorRequiredForRole(role string) bool {
+	configured, ok := os.LookupEnv("TWO_FACTOR_REQUIRED_ROLES")
+	if !ok {
+		configured = models.RoleSecurity + "," + models.RoleAdmin
+	}
+
+	for _, r := range strings.Split(configured, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupTwoFactor generates a new secret for the user to add to their
+// authenticator app. It only takes effect once confirmed with EnableTwoFactor.
+// Secrets are stored sealed with utility.EncryptString, as a leaked secret
+// would let anyone generate the user's codes.
+func SetupTwoFactor(db *gorm.DB, userID string) (*models.TwoFactorSetupResponse, int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	if user.IsTwoFactorEnabled() {
+		return nil, http.StatusConflict, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := utility.GenerateTOTPSecret()
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to generate secret")
+	}
+
+	sealed, err := utility.EncryptString(secret)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to encrypt secret: %v", err)
+	}
+	if err := db.Model(&user).Update("two_factor_pending_secret", sealed).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save secret: %v", err)
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utility.TOTPProvisioningURI(twoFactorIssuer(), user.Email, secret),
+	}, http.StatusOK, nil
+}
+
+// EnableTwoFactor confirms the pending secret with a code from the
+// authenticator and returns a fresh set of recovery codes.
+func EnableTwoFactor(db *gorm.DB, actor models.AuditActor, userID, code string) (*models.TwoFactorRecoveryCodesResponse, int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	if user.IsTwoFactorEnabled() {
+		return nil, http.StatusConflict, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TwoFactorPendingSecret == "" {
+		return nil, http.StatusBadRequest, errors.New("start two-factor setup first")
+	}
+
+	secret, err := utility.DecryptString(user.TwoFactorPendingSecret)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to read pending secret: %v", err)
+	}
+	step, ok := utility.ValidateTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, http.StatusBadRequest, errInvalidSecondFactor
+	}
+
+	var codes []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&user).Updates(map[string]any{
+			"two_factor_secret":         user.TwoFactorPendingSecret,
+			"two_factor_pending_secret": "",
+			"two_factor_enabled_at":     now,
+			"two_factor_last_step":      step,
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		if codes, err = replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditTwoFactorEnabled, AuditTargetUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to enable two-factor authentication: %v", err)
+	}
+
+	return &models.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, http.StatusOK, nil
+}
+
+func DisableTwoFactor(db *gorm.DB, actor models.AuditActor, userID string, input models.DisableTwoFactorInput) (int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return http.StatusNotFound, errors.New("user not found")
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		return http.StatusBadRequest, errors.New("two-factor authentication is not enabled")
+	}
+	if TwoFactorRequiredForRole(user.Role) {
+		return http.StatusForbidden, fmt.Errorf("two-factor authentication is mandatory for the %s role", user.Role)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		return http.StatusUnauthorized, errors.New("password is incorrect")
+	}
+	if !verifyTOTP(db, &user, input.Code) {
+		return http.StatusBadRequest, errInvalidSecondFactor
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]any{
+			"two_factor_secret":     "",
+			"two_factor_enabled_at": nil,
+			"two_factor_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditTwoFactorDisabled, AuditTargetUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to disable two-factor authentication: %v", err)
+	}
+
+	return http.StatusOK, nil
+}
+
+// RegenerateRecoveryCodes invalidates the user's recovery codes and issues new ones.
+func RegenerateRecoveryCodes(db *gorm.DB, actor models.AuditActor, userID, code string) (*models.TwoFactorRecoveryCodesResponse, int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		return nil, http.StatusBadRequest, errors.New("two-factor authentication is not enabled")
+	}
+	if !verifyTOTP(db, &user, code) {
+		return nil, http.StatusBadRequest, errInvalidSecondFactor
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if codes, err = replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditRecoveryCodesReset, AuditTargetUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to regenerate recovery codes: %v", err)
+	}
+
+	return &models.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, http.StatusOK, nil
+}
+
+func generateTwoFactorChallenge(user *models.User) (string, error) {
+	return keyManager.Sign(newTokenClaims(user.ID, twoFactorChallengeType, utility.GetEnvDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute)))
+}
+
+// CompleteTwoFactorLogin finishes a login started with a password by checking
+// the second factor against the challenge token returned from Login.
+func CompleteTwoFactorLogin(db *gorm.DB, input models.TwoFactorLoginInput, client models.ClientInfo) (*models.User, int, error) {
+	ip := client.IPAddress
+
+	claims, err := parseToken(input.ChallengeToken, twoFactorChallengeType)
+	if err != nil {
+		return nil, http.StatusUnauthorized, errors.New("challenge token is invalid or has expired")
+	}
+
+	revoked, err := IsAccessTokenRevoked(db, claims)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if revoked {
+		return nil, http.StatusUnauthorized, errors.New("challenge token has already been used")
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", claims["sub"]).First(&user).Error; err != nil {
+		return nil, http.StatusUnauthorized, errors.New("challenge token is invalid or has expired")
+	}
+
+	if code, err := checkLoginAllowed(user.Email, ip); err != nil {
+		return nil, code, err
+	}
+
+	verified := false
+	if input.RecoveryCode != "" {
+		verified = useRecoveryCode(db, user.ID, input.RecoveryCode)
+	} else {
+		verified = verifyTOTP(db, &user, input.Code)
+	}
+	if !verified {
+		recordLoginFailure(db, user.Email, ip, &user.ID)
+		return nil, http.StatusUnauthorized, errInvalidSecondFactor
+	}
+
+	jti, _ := claims["jti"].(string)
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		if err := RevokeAccessToken(db, jti, user.ID, expiresAt.Time); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	recordLoginSuccess(user.Email)
+
+	if code, err := startSession(db, &user, client); err != nil {
+		return nil, code, err
+	}
+
+	return &user, http.StatusOK, nil
+}
+
+// verifyTOTP checks a code for an enrolled user and refuses codes from a time
+// step that has already been used.
+func verifyTOTP(db *gorm.DB, user *models.User, code string) bool {
+	secret, err := utility.DecryptString(user.TwoFactorSecret)
+	if err != nil {
+		log.Println("Failed to read two-factor secret:", err)
+		return false
+	}
+	step, ok := utility.ValidateTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return false
+	}
+
+	result := db.Model(&models.User{}).
+		Where("id = ? AND two_factor_last_step < ?", user.ID, step).
+		Update("two_factor_last_step", step)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func useRecoveryCode(db *gorm.DB, userID, code string) bool {
+	hash := utility.HashToken(normalizeRecoveryCode(code))
+
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		secret, err := utility.GenerateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(secret[:5] + "-" + secret[5:10])
+		if slices.Contains(codes, code) {
+			continue
+		}
+
+		record := models.RecoveryCode{UserID: userID, CodeHash: utility.HashToken(normalizeRecoveryCode(code))}
+		if err := tx.Create(&record).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
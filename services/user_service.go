@@ -1,24 +1,75 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"survielx-backend/connections"
 	"survielx-backend/database"
 	"survielx-backend/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+var ErrAccountSuspended = errors.New("account has been suspended")
+
 func CreateUser(db *gorm.DB, user *models.User) error {
 	result := db.Create(user)
 	return result.Error
 }
 
-func GetUsers(db *gorm.DB) ([]models.User, error) {
-	var users []models.User
-	result := db.Find(&users)
-	if result.Error != nil {
-		return nil, result.Error
+// GetUsers returns a page of users matching the filters.
+func GetUsers(db *gorm.DB, pagination models.Pagination, filters models.UserFilters) (*models.PaginatedResponse, int, error) {
+	var (
+		users []models.User
+		count int64
+	)
+
+	query := db.Model(&models.User{})
+	if filters.Search != "" {
+		search := "%" + filters.Search + "%"
+		query = query.Where("name ILIKE ? OR email ILIKE ?", search, search)
+	}
+	if filters.Role != "" {
+		query = query.Where("role = ?", filters.Role)
+	}
+	if filters.Email != "" {
+		query = query.Where("email ILIKE ?", "%"+filters.Email+"%")
+	}
+	if filters.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+filters.Name+"%")
 	}
-	return users, nil
+	switch filters.Status {
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count users: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("created_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&users).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch users: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: users,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(users),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
 }
 
 func GetUserByID(userID string) (models.User, error) {
@@ -28,3 +79,155 @@ func GetUserByID(userID string) (models.User, error) {
 	}
 	return user, nil
 }
+
+// GetUserDetails returns a user with their profile and vehicles.
+func GetUserDetails(db *gorm.DB, userID string) (*models.UserDetailsResponse, int, error) {
+	var details models.UserDetailsResponse
+	if err := db.Where("id = ?", userID).First(&details.User).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	var profile models.Profile
+	if err := profile.GetUserProfile(db, userID); err == nil {
+		details.Profile = &profile
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("created_at desc").Find(&details.Vehicles).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch vehicles: %v", err)
+	}
+
+	return &details, http.StatusOK, nil
+}
+
+// ChangeUserRole moves a user to another role. Admins cannot change their own
+// role so the last admin cannot lock everyone out.
+func ChangeUserRole(db *gorm.DB, actor models.AuditActor, userID, role string) (*models.User, int, error) {
+	if actor.ID == userID {
+		return nil, http.StatusBadRequest, errors.New("you cannot change your own role")
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	if user.Role == role {
+		return &user, http.StatusOK, nil
+	}
+
+	before := user
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+		if role == models.RoleSecurity && !models.CheckExists(tx, &models.Security{}, "user_id = ?", user.ID) {
+			if err := tx.Create(&models.Security{UserID: user.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, actor, AuditUserRoleChanged, AuditTargetUser, user.ID, before, user)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to change role: %v", err)
+	}
+
+	return &user, http.StatusOK, nil
+}
+
+// SuspendUser blocks a user from signing in and ends all of their sessions.
+func SuspendUser(db *gorm.DB, actor models.AuditActor, userID, reason string) (*models.User, int, error) {
+	if actor.ID == userID {
+		return nil, http.StatusBadRequest, errors.New("you cannot suspend your own account")
+	}
+
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	if user.IsSuspended() {
+		return nil, http.StatusBadRequest, errors.New("user is already suspended")
+	}
+
+	before := user
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]any{
+			"suspended_at":      now,
+			"suspension_reason": reason,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to suspend user: %v", err)
+		}
+
+		if err := RevokeAllUserTokens(tx, user.ID); err != nil {
+			return fmt.Errorf("failed to end user sessions: %v", err)
+		}
+
+		return recordAudit(tx, actor, AuditUserSuspended, AuditTargetUser, user.ID, before, user)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	connections.CloseUser(user.ID)
+
+	return &user, http.StatusOK, nil
+}
+
+func ReactivateUser(db *gorm.DB, actor models.AuditActor, userID string) (*models.User, int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, http.StatusNotFound, errors.New("user not found")
+	}
+
+	if !user.IsSuspended() {
+		return nil, http.StatusBadRequest, errors.New("user is not suspended")
+	}
+
+	before := user
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]any{
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditUserReactivated, AuditTargetUser, user.ID, before, user)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to reactivate user: %v", err)
+	}
+
+	return &user, http.StatusOK, nil
+}
+
+// AdminResetPassword signs the user out everywhere and emails them a link to
+// choose a new password.
+func AdminResetPassword(db *gorm.DB, actor models.AuditActor, userID string) (int, error) {
+	var user models.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return http.StatusNotFound, errors.New("user not found")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := RevokeAllUserTokens(tx, user.ID); err != nil {
+			return fmt.Errorf("failed to end user sessions: %v", err)
+		}
+		return recordAudit(tx, actor, AuditUserPasswordResetSent, AuditTargetUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	connections.CloseUser(user.ID)
+
+	return sendPasswordResetEmail(db, &user)
+}
+
+func accountSuspended(db *gorm.DB, userID string) (bool, error) {
+	var user models.User
+	if err := db.Select("id", "suspended_at").Where("id = ?", userID).First(&user).Error; err != nil {
+		return false, err
+	}
+	return user.IsSuspended(), nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidUserToken = errors.New("token is invalid or has expired")
+
+// issueUserToken creates a single-use token for the user and returns the raw
+// value that should be delivered to them. Earlier unused tokens with the same
+// purpose are invalidated.
+func issueUserToken(db *gorm.DB, userID string, purpose models.UserTokenPurpose, ttl time.Duration) (string, error) {
+	raw, err := utility.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		token := models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utility.HashToken(raw),
+			ExpiresAt: time.Now().Add(ttl),
+		}
+		return tx.Create(&token).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+// consumeUserToken marks a token as used and returns it. It fails when the
+// token is unknown, expired, already used or issued for another purpose.
+func consumeUserToken(db *gorm.DB, raw string, purpose models.UserTokenPurpose) (*models.UserToken, error) {
+	var token models.UserToken
+	err := db.Where("token_hash = ? AND purpose = ?", utility.HashToken(raw), purpose).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	result := db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidUserToken
+	}
+
+	return &token, nil
+}
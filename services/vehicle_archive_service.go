@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"survielx-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeRegisterVehicle archives one of the owner's vehicles. Its open transfers
+// are cancelled, its drivers lose access and the owner's ownership record is
+// closed. A vehicle that is still inside has to exit first.
+func DeRegisterVehicle(db *gorm.DB, actor models.AuditActor, vehicleID string, input models.DeRegisterVehicleInput) (int, error) {
+	var (
+		vehicle models.Vehicle
+		code    = http.StatusOK
+	)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, "id = ?", vehicleID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+				return errors.New("vehicle does not exist")
+			}
+			code = http.StatusInternalServerError
+			return err
+		}
+		if vehicle.UserID != actor.ID {
+			code = http.StatusForbidden
+			return errors.New("only the owner can deregister this vehicle")
+		}
+
+		inside, err := isVehicleInside(tx, vehicle.ID)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return err
+		}
+		if inside {
+			code = http.StatusConflict
+			return errors.New("vehicle is inside and can only be deregistered after it exits")
+		}
+
+		before := vehicle
+		if err := archiveVehicle(tx, &vehicle, actor.ID, strings.TrimSpace(input.Reason), time.Now()); err != nil {
+			code = http.StatusInternalServerError
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleDeregistered, AuditTargetVehicle, vehicle.ID, before, vehicle)
+	})
+	if err != nil {
+		return code, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// archiveVehicle takes a vehicle out of service: its open transfers are
+// cancelled, its drivers lose access and its current ownership is closed.
+func archiveVehicle(tx *gorm.DB, vehicle *models.Vehicle, archivedByID, reason string, at time.Time) error {
+	if err := tx.Model(&models.VehicleTransfer{}).
+		Where("vehicle_id = ? AND status IN ?", vehicle.ID, openTransferStatuses()).
+		Updates(map[string]any{"status": models.TransferStatusCancelled, "closed_at": at}).Error; err != nil {
+		return err
+	}
+	if err := revokeVehicleDrivers(tx, vehicle.ID, at); err != nil {
+		return err
+	}
+	if err := ensureVehicleOwnership(tx, *vehicle); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.VehicleOwnership{}).
+		Where("vehicle_id = ? AND ended_at IS NULL", vehicle.ID).
+		Update("ended_at", at).Error; err != nil {
+		return err
+	}
+
+	return vehicle.Archive(tx, archivedByID, reason)
+}
+
+// GetArchivedVehicles lists deregistered vehicles, most recently archived
+// first.
+func GetArchivedVehicles(db *gorm.DB, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		vehicles []models.Vehicle
+		count    int64
+	)
+
+	query := db.Unscoped().Model(&models.Vehicle{}).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count vehicles: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("deleted_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&vehicles).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch vehicles: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: vehicles,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(vehicles),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
+
+// RestoreVehicle brings an archived, approved vehicle back into service. For
+// its last owner the record itself is restored. Giving the email of another
+// user registers the plate to them as a new, approved vehicle and leaves the
+// archived record and its history with the previous owner. The plate must not
+// have been registered to another vehicle in the meantime.
+func RestoreVehicle(db *gorm.DB, actor models.AuditActor, vehicleID string, input models.RestoreVehicleInput) (*models.Vehicle, int, error) {
+	var (
+		vehicle  models.Vehicle
+		restored models.Vehicle
+		code     = http.StatusOK
+	)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, "id = ?", vehicleID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+				return errors.New("vehicle does not exist")
+			}
+			code = http.StatusInternalServerError
+			return err
+		}
+		if !vehicle.DeletedAt.Valid {
+			code = http.StatusBadRequest
+			return errors.New("vehicle is not archived")
+		}
+		if !vehicle.IsApproved() {
+			code = http.StatusConflict
+			return fmt.Errorf("only approved vehicles can be restored; this one is %s and has to be registered again", vehicle.Status)
+		}
+		if models.CheckExists(tx, &models.Vehicle{}, "normalized_plate = ?", vehicle.NormalizedPlate) {
+			code = http.StatusConflict
+			return fmt.Errorf("plate number %s has been registered again since this vehicle was archived", vehicle.PlateNumber)
+		}
+
+		ownerID := vehicle.UserID
+		if email := strings.ToLower(strings.TrimSpace(input.Email)); email != "" {
+			var owner models.User
+			if !models.CheckExists(tx, &owner, "LOWER(email) = ?", email) || owner.IsSuspended() {
+				code = http.StatusBadRequest
+				return errors.New("the vehicle cannot be restored to this email")
+			}
+			ownerID = owner.ID
+		}
+
+		if ownerID != vehicle.UserID {
+			restored, err = registerArchivedPlate(tx, actor, vehicle, ownerID)
+		} else {
+			restored, err = restoreArchivedVehicle(tx, actor, vehicle)
+		}
+		if err != nil {
+			code = http.StatusInternalServerError
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &restored, http.StatusOK, nil
+}
+
+// restoreArchivedVehicle puts an archived vehicle back into service under its
+// last owner, starting a new period of ownership.
+func restoreArchivedVehicle(tx *gorm.DB, actor models.AuditActor, vehicle models.Vehicle) (models.Vehicle, error) {
+	var restored models.Vehicle
+	err := tx.Unscoped().Model(&vehicle).Updates(map[string]any{
+		"deleted_at":     nil,
+		"archived_by_id": nil,
+		"archive_reason": "",
+	}).Error
+	if err != nil {
+		return restored, err
+	}
+
+	if err := tx.Create(&models.VehicleOwnership{
+		VehicleID:   vehicle.ID,
+		UserID:      vehicle.UserID,
+		PlateNumber: vehicle.PlateNumber,
+		StartedAt:   time.Now(),
+	}).Error; err != nil {
+		return restored, err
+	}
+	if err := ensureVehicleOwnerDriver(tx, vehicle); err != nil {
+		return restored, err
+	}
+
+	if err := tx.First(&restored, "id = ?", vehicle.ID).Error; err != nil {
+		return restored, err
+	}
+	return restored, recordAudit(tx, actor, AuditVehicleRestored, AuditTargetVehicle, restored.ID, vehicle, restored)
+}
+
+// registerArchivedPlate registers an archived vehicle's plate to a new owner
+// as a new vehicle. The admin restoring it counts as its reviewer.
+func registerArchivedPlate(tx *gorm.DB, actor models.AuditActor, archived models.Vehicle, ownerID string) (models.Vehicle, error) {
+	now := time.Now()
+	vehicle := models.Vehicle{
+		UserID:        ownerID,
+		PlateNumber:   archived.PlateNumber,
+		PlateCountry:  archived.PlateCountry,
+		PlateCategory: archived.PlateCategory,
+		Type:          archived.Type,
+		Model:         archived.Model,
+		Color:         archived.Color,
+		Status:        models.VehicleStatusApproved,
+		ReviewedByID:  &actor.ID,
+		ReviewedAt:    &now,
+		ReviewReason:  "restored from archived vehicle " + archived.ID,
+	}
+	return createVehicle(tx, actor, AuditVehicleRestored, &vehicle)
+}
+
+// isVehicleInside reports whether a vehicle's last logged activity was an
+// entry.
+func isVehicleInside(db *gorm.DB, vehicleID string) (bool, error) {
+	var lastLog models.VehicleActivity
+	err := db.Where("vehicle_id = ?", vehicleID).
+		Order("timestamp desc").
+		Limit(1).
+		Find(&lastLog).Error
+	if err != nil {
+		return false, err
+	}
+
+	return lastLog.ID != "" && lastLog.IsEntry, nil
+}
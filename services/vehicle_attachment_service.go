@@ -0,0 +1,183 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"survielx-backend/models"
+	"survielx-backend/storage"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const attachmentThumbnailSize = 320
+
+var attachmentExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// AttachmentMaxBytes is the largest file accepted as a vehicle attachment.
+func AttachmentMaxBytes() int64 {
+	return int64(utility.GetEnvInt("ATTACHMENT_MAX_BYTES", 10<<20))
+}
+
+func attachmentURLTTL() time.Duration {
+	return utility.GetEnvDuration("ATTACHMENT_URL_TTL", 15*time.Minute)
+}
+
+// UploadVehicleAttachment checks an uploaded file against the kind of
+// attachment, stores it with a thumbnail for images and records it. Anyone
+// authorized to drive the vehicle may upload.
+func UploadVehicleAttachment(db *gorm.DB, actor models.AuditActor, vehicleID, kind, fileName string, data []byte) (*models.VehicleAttachment, int, error) {
+	if !models.CheckExists(db, &models.Vehicle{}, "id = ?", vehicleID) {
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+	}
+	if !isAuthorizedDriver(db, vehicleID, actor.ID) {
+		return nil, http.StatusForbidden, errors.New("you are not authorized to drive this vehicle")
+	}
+
+	allowed, ok := models.AttachmentKinds[kind]
+	if !ok {
+		return nil, http.StatusBadRequest, fmt.Errorf("unknown attachment kind %q", kind)
+	}
+	if len(data) == 0 {
+		return nil, http.StatusBadRequest, errors.New("file is empty")
+	}
+	if int64(len(data)) > AttachmentMaxBytes() {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("file is larger than %d bytes", AttachmentMaxBytes())
+	}
+
+	// trust the file's content, not the name or header the client sent
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(allowed, contentType) {
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("%s is not accepted for %s", contentType, kind)
+	}
+
+	checksum := sha256.Sum256(data)
+	attachment := models.VehicleAttachment{
+		ID:           utility.GenerateUUID(),
+		VehicleID:    vehicleID,
+		Kind:         kind,
+		FileName:     fileName,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Checksum:     hex.EncodeToString(checksum[:]),
+		UploadedByID: actor.ID,
+	}
+	attachment.StorageKey = fmt.Sprintf("vehicles/%s/%s%s", vehicleID, attachment.ID, attachmentExtensions[contentType])
+
+	var thumbnail []byte
+	if contentType != "application/pdf" {
+		var err error
+		thumbnail, err = utility.MakeThumbnail(data, attachmentThumbnailSize)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("image could not be read: %v", err)
+		}
+		attachment.ThumbnailKey = fmt.Sprintf("vehicles/%s/%s_thumb.jpg", vehicleID, attachment.ID)
+	}
+
+	if err := storage.Default.Put(attachment.StorageKey, data, contentType); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to store file: %v", err)
+	}
+	if thumbnail != nil {
+		if err := storage.Default.Put(attachment.ThumbnailKey, thumbnail, "image/jpeg"); err != nil {
+			deleteAttachmentBlobs(attachment)
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to store thumbnail: %v", err)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attachment).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleAttachmentAdded, AuditTargetVehicleAttachment, attachment.ID, nil, attachment)
+	})
+	if err != nil {
+		deleteAttachmentBlobs(attachment)
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to save attachment: %v", err)
+	}
+
+	signAttachmentURLs(&attachment)
+	return &attachment, http.StatusCreated, nil
+}
+
+// GetVehicleAttachments lists a vehicle's attachments with signed download
+// links. Pass an empty userID to skip the driver check, as security does.
+func GetVehicleAttachments(db *gorm.DB, vehicleID, userID string) ([]models.VehicleAttachment, int, error) {
+	if !models.CheckExists(db, &models.Vehicle{}, "id = ?", vehicleID) {
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+	}
+	if userID != "" && !isAuthorizedDriver(db, vehicleID, userID) {
+		return nil, http.StatusForbidden, errors.New("you are not authorized to drive this vehicle")
+	}
+
+	var attachments []models.VehicleAttachment
+	if err := db.Where("vehicle_id = ?", vehicleID).Order("created_at desc").Find(&attachments).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch attachments: %v", err)
+	}
+
+	for i := range attachments {
+		signAttachmentURLs(&attachments[i])
+	}
+	return attachments, http.StatusOK, nil
+}
+
+func DeleteVehicleAttachment(db *gorm.DB, actor models.AuditActor, vehicleID, attachmentID string) (int, error) {
+	var attachment models.VehicleAttachment
+	if !models.CheckExists(db, &attachment, "id = ? AND vehicle_id = ?", attachmentID, vehicleID) {
+		return http.StatusNotFound, errors.New("attachment not found")
+	}
+	if !isAuthorizedDriver(db, vehicleID, actor.ID) {
+		return http.StatusForbidden, errors.New("you are not authorized to drive this vehicle")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleAttachmentRemoved, AuditTargetVehicleAttachment, attachment.ID, attachment, nil)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to delete attachment: %v", err)
+	}
+
+	deleteAttachmentBlobs(attachment)
+	return http.StatusOK, nil
+}
+
+func signAttachmentURLs(attachment *models.VehicleAttachment) {
+	ttl := attachmentURLTTL()
+
+	url, err := storage.Default.SignedURL(attachment.StorageKey, ttl)
+	if err != nil {
+		log.Println("Failed to sign attachment URL:", err)
+	}
+	attachment.URL = url
+
+	if attachment.ThumbnailKey != "" {
+		url, err := storage.Default.SignedURL(attachment.ThumbnailKey, ttl)
+		if err != nil {
+			log.Println("Failed to sign thumbnail URL:", err)
+		}
+		attachment.ThumbnailURL = url
+	}
+}
+
+func deleteAttachmentBlobs(attachment models.VehicleAttachment) {
+	for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := storage.Default.Delete(key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"survielx-backend/mailer"
+	"survielx-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errDriverAlreadyAuthorized  = errors.New("this user is already invited to drive this vehicle")
+	errDriverInviteeUnavailable = errors.New("this email cannot be invited to drive the vehicle")
+)
+
+// InviteVehicleDriver asks another user to share the owner's vehicle. They
+// become an authorized driver once they accept.
+func InviteVehicleDriver(db *gorm.DB, actor models.AuditActor, vehicleID string, input models.InviteVehicleDriverInput) (*models.VehicleDriver, int, error) {
+	var vehicle models.Vehicle
+	if !models.CheckExists(db, &vehicle, "id = ?", vehicleID) {
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+	}
+	if vehicle.UserID != actor.ID {
+		return nil, http.StatusForbidden, errors.New("only the owner can add drivers to this vehicle")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	// unknown and suspended accounts get the same answer, so the endpoint
+	// cannot be used to discover who is registered
+	var invitee models.User
+	if !models.CheckExists(db, &invitee, "LOWER(email) = ?", email) || invitee.IsSuspended() {
+		return nil, http.StatusBadRequest, errDriverInviteeUnavailable
+	}
+	if invitee.ID == vehicle.UserID {
+		return nil, http.StatusBadRequest, errors.New("you already own this vehicle")
+	}
+
+	driver := models.VehicleDriver{
+		VehicleID:   vehicle.ID,
+		UserID:      invitee.ID,
+		Role:        models.VehicleRoleDriver,
+		Status:      models.VehicleDriverPending,
+		InvitedByID: &actor.ID,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, "id = ?", vehicle.ID).Error; err != nil {
+			return err
+		}
+		if err := ensureVehicleOwnerDriver(tx, vehicle); err != nil {
+			return err
+		}
+		if models.CheckExists(tx, &models.VehicleDriver{}, "vehicle_id = ? AND user_id = ? AND status IN ?",
+			vehicle.ID, invitee.ID, []string{models.VehicleDriverPending, models.VehicleDriverActive}) {
+			return errDriverAlreadyAuthorized
+		}
+		if err := tx.Create(&driver).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleDriverInvited, AuditTargetVehicleDriver, driver.ID, nil, driver)
+	})
+	if errors.Is(err, errDriverAlreadyAuthorized) {
+		return nil, http.StatusConflict, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to invite driver: %v", err)
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nYou have been invited to drive vehicle %s.\n"+
+		"Sign in to SurvielX to accept or decline. Once you accept, you will be asked to confirm this vehicle's exits.\n",
+		invitee.Name, vehicle.PlateNumber)
+
+	err = mailer.Send(mailer.Message{
+		To:      []string{invitee.Email},
+		Subject: "You have been invited to share a vehicle",
+		Body:    body,
+	})
+	if err != nil {
+		log.Println("Failed to send driver invitation email:", err)
+	}
+
+	return &driver, http.StatusCreated, nil
+}
+
+// GetVehicleDrivers lists everyone authorized or invited to drive one of the
+// owner's vehicles.
+func GetVehicleDrivers(db *gorm.DB, vehicleID, ownerID string) ([]models.VehicleDriver, int, error) {
+	var vehicle models.Vehicle
+	if !models.CheckExists(db, &vehicle, "id = ?", vehicleID) {
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+	}
+	if vehicle.UserID != ownerID {
+		return nil, http.StatusForbidden, errors.New("you do not own this vehicle")
+	}
+
+	if err := ensureVehicleOwnerDriver(db, vehicle); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to record vehicle owner: %v", err)
+	}
+
+	var drivers []models.VehicleDriver
+	if err := db.Preload("User").
+		Where("vehicle_id = ? AND status IN ?", vehicleID, []string{models.VehicleDriverPending, models.VehicleDriverActive}).
+		Order("created_at asc").
+		Find(&drivers).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch drivers: %v", err)
+	}
+
+	return drivers, http.StatusOK, nil
+}
+
+// GetSharedVehicles lists the driver invitations and shared vehicles of a
+// user who does not own them.
+func GetSharedVehicles(db *gorm.DB, userID string) ([]models.VehicleDriver, int, error) {
+	var drivers []models.VehicleDriver
+	if err := db.Preload("Vehicle").
+		Where("user_id = ? AND role = ? AND status IN ?", userID, models.VehicleRoleDriver,
+			[]string{models.VehicleDriverPending, models.VehicleDriverActive}).
+		Order("created_at desc").
+		Find(&drivers).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch shared vehicles: %v", err)
+	}
+
+	return drivers, http.StatusOK, nil
+}
+
+func AcceptVehicleDriver(db *gorm.DB, actor models.AuditActor, driverID string) (*models.VehicleDriver, int, error) {
+	return updateVehicleDriver(db, actor, driverID, func(driver *models.VehicleDriver, vehicle models.Vehicle) (string, int, error) {
+		if driver.UserID != actor.ID {
+			return "", http.StatusForbidden, errors.New("only the invited user can accept this invitation")
+		}
+		if driver.Status != models.VehicleDriverPending {
+			return "", http.StatusBadRequest, errors.New("invitation is no longer pending")
+		}
+
+		now := time.Now()
+		driver.Status = models.VehicleDriverActive
+		driver.AcceptedAt = &now
+		return AuditVehicleDriverAccepted, http.StatusOK, nil
+	})
+}
+
+func DeclineVehicleDriver(db *gorm.DB, actor models.AuditActor, driverID string) (*models.VehicleDriver, int, error) {
+	return updateVehicleDriver(db, actor, driverID, func(driver *models.VehicleDriver, vehicle models.Vehicle) (string, int, error) {
+		if driver.UserID != actor.ID {
+			return "", http.StatusForbidden, errors.New("only the invited user can decline this invitation")
+		}
+		if driver.Status != models.VehicleDriverPending {
+			return "", http.StatusBadRequest, errors.New("invitation is no longer pending")
+		}
+
+		driver.Status = models.VehicleDriverDeclined
+		return AuditVehicleDriverDeclined, http.StatusOK, nil
+	})
+}
+
+// RevokeVehicleDriver removes a driver. The owner may remove anyone; a driver
+// may remove themselves.
+func RevokeVehicleDriver(db *gorm.DB, actor models.AuditActor, driverID string) (*models.VehicleDriver, int, error) {
+	return updateVehicleDriver(db, actor, driverID, func(driver *models.VehicleDriver, vehicle models.Vehicle) (string, int, error) {
+		if vehicle.UserID != actor.ID && driver.UserID != actor.ID {
+			return "", http.StatusForbidden, errors.New("you cannot remove this driver")
+		}
+		if driver.Role == models.VehicleRoleOwner {
+			return "", http.StatusBadRequest, errors.New("the owner cannot be removed; transfer the vehicle instead")
+		}
+		if driver.Status != models.VehicleDriverPending && driver.Status != models.VehicleDriverActive {
+			return "", http.StatusBadRequest, errors.New("driver has already been removed")
+		}
+
+		now := time.Now()
+		driver.Status = models.VehicleDriverRevoked
+		driver.RevokedAt = &now
+		return AuditVehicleDriverRevoked, http.StatusOK, nil
+	})
+}
+
+// updateVehicleDriver locks a driver record and its vehicle, applies a state
+// change and saves and audits the result in one transaction.
+func updateVehicleDriver(db *gorm.DB, actor models.AuditActor, driverID string, apply func(driver *models.VehicleDriver, vehicle models.Vehicle) (string, int, error)) (*models.VehicleDriver, int, error) {
+	var (
+		driver  models.VehicleDriver
+		vehicle models.Vehicle
+		code    = http.StatusOK
+	)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&driver, "id = ?", driverID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+				return errors.New("driver not found")
+			}
+			code = http.StatusInternalServerError
+			return err
+		}
+		if err := tx.First(&vehicle, "id = ?", driver.VehicleID).Error; err != nil {
+			code = http.StatusNotFound
+			return errors.New("vehicle does not exist")
+		}
+
+		before := driver
+		action, applyCode, err := apply(&driver, vehicle)
+		if err != nil {
+			code = applyCode
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Save(&driver).Error; err != nil {
+			code = http.StatusInternalServerError
+			return err
+		}
+		return recordAudit(tx, actor, action, AuditTargetVehicleDriver, driver.ID, before, driver)
+	})
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &driver, http.StatusOK, nil
+}
+
+// ensureVehicleOwnerDriver records the owner of a vehicle registered before
+// drivers were tracked.
+func ensureVehicleOwnerDriver(tx *gorm.DB, vehicle models.Vehicle) error {
+	if models.CheckExists(tx, &models.VehicleDriver{}, "vehicle_id = ? AND user_id = ? AND role = ? AND status = ?",
+		vehicle.ID, vehicle.UserID, models.VehicleRoleOwner, models.VehicleDriverActive) {
+		return nil
+	}
+
+	now := time.Now()
+	return tx.Create(&models.VehicleDriver{
+		VehicleID:  vehicle.ID,
+		UserID:     vehicle.UserID,
+		Role:       models.VehicleRoleOwner,
+		Status:     models.VehicleDriverActive,
+		AcceptedAt: &now,
+	}).Error
+}
+
+// replaceVehicleDrivers revokes everyone authorized on a vehicle that has
+// changed hands and makes the new owner its only authorized user.
+func replaceVehicleDrivers(tx *gorm.DB, vehicle models.Vehicle, newOwnerID string) error {
+	if err := revokeVehicleDrivers(tx, vehicle.ID, time.Now()); err != nil {
+		return err
+	}
+
+	vehicle.UserID = newOwnerID
+	return ensureVehicleOwnerDriver(tx, vehicle)
+}
+
+// revokeVehicleDrivers revokes every pending and active driver of a vehicle,
+// its owner included.
+func revokeVehicleDrivers(tx *gorm.DB, vehicleID string, at time.Time) error {
+	return tx.Model(&models.VehicleDriver{}).
+		Where("vehicle_id = ? AND status IN ?", vehicleID, []string{models.VehicleDriverPending, models.VehicleDriverActive}).
+		Updates(map[string]any{"status": models.VehicleDriverRevoked, "revoked_at": at}).Error
+}
+
+// authorizedDriverIDs returns the owner and active drivers of a vehicle.
+func authorizedDriverIDs(db *gorm.DB, vehicle models.Vehicle) ([]string, error) {
+	var userIDs []string
+	err := db.Model(&models.VehicleDriver{}).
+		Where("vehicle_id = ? AND status = ? AND user_id <> ?", vehicle.ID, models.VehicleDriverActive, vehicle.UserID).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{vehicle.UserID}, userIDs...), nil
+}
+
+// isAuthorizedDriver reports whether a user owns or may drive a vehicle.
+func isAuthorizedDriver(db *gorm.DB, vehicleID, userID string) bool {
+	if models.CheckExists(db, &models.Vehicle{}, "id = ? AND user_id = ?", vehicleID, userID) {
+		return true
+	}
+	return models.CheckExists(db, &models.VehicleDriver{}, "vehicle_id = ? AND user_id = ? AND status = ?",
+		vehicleID, userID, models.VehicleDriverActive)
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VehicleImportMaxBytes is the largest CSV file accepted for an import.
+func VehicleImportMaxBytes() int64 {
+	return int64(utility.GetEnvInt("VEHICLE_IMPORT_MAX_BYTES", 5<<20))
+}
+
+func vehicleImportMaxRows() int {
+	return utility.GetEnvInt("VEHICLE_IMPORT_MAX_ROWS", 5000)
+}
+
+type vehicleImportRow struct {
+	line       int
+	ownerEmail string
+	plate      string
+	vehicle    models.Vehicle
+	// rejected is the rejected registration the row registers again
+	rejected *models.Vehicle
+	errors   []string
+}
+
+// vehicleImportLookup holds what checking an import needs from the database.
+type vehicleImportLookup struct {
+	owners       map[string]models.User    // by lower case email
+	vehicles     map[string]models.Vehicle // active vehicles by normalized plate
+	vehicleTypes map[string]bool           // active type codes
+}
+
+func (row *vehicleImportRow) addError(format string, args ...any) {
+	row.errors = append(row.errors, fmt.Sprintf(format, args...))
+}
+
+// ImportVehicles registers residents' vehicles from a CSV laid out as
+// models.VehicleCSVHeader. Every row is checked first. A dry run stops there
+// and reports the problems; a commit registers all valid rows in one
+// transaction and skips the rest. Imported vehicles are approved, the admin
+// running the import standing in for security's review.
+func ImportVehicles(db *gorm.DB, actor models.AuditActor, mode string, r io.Reader) (*models.VehicleImportResult, int, error) {
+	if mode == "" {
+		mode = models.VehicleImportDryRun
+	}
+	if mode != models.VehicleImportDryRun && mode != models.VehicleImportCommit {
+		return nil, http.StatusBadRequest, fmt.Errorf("mode must be %s or %s", models.VehicleImportDryRun, models.VehicleImportCommit)
+	}
+
+	rows, code, err := readVehicleImport(r)
+	if err != nil {
+		return nil, code, err
+	}
+	lookup, err := loadVehicleImportLookup(db, rows)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to check rows: %v", err)
+	}
+	checkVehicleImportRows(rows, lookup)
+
+	result := models.VehicleImportResult{
+		Mode:      mode,
+		TotalRows: len(rows),
+		RowErrors: []models.VehicleImportRowError{},
+	}
+	var valid []vehicleImportRow
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			result.RowErrors = append(result.RowErrors, models.VehicleImportRowError{
+				Row:         row.line,
+				PlateNumber: row.vehicle.PlateNumber,
+				Errors:      row.errors,
+			})
+			continue
+		}
+		valid = append(valid, row)
+	}
+	result.ValidRows = len(valid)
+
+	if mode == models.VehicleImportDryRun || len(valid) == 0 {
+		return &result, http.StatusOK, nil
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range valid {
+			vehicle := row.vehicle
+			vehicle.Status = models.VehicleStatusApproved
+			vehicle.ReviewedByID = &actor.ID
+			vehicle.ReviewedAt = &now
+
+			imported, err := importVehicle(tx, actor, row.rejected, &vehicle)
+			if err != nil {
+				return fmt.Errorf("plate number %s: %v", vehicle.PlateNumber, err)
+			}
+			result.VehicleIDs = append(result.VehicleIDs, imported.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to import vehicles: %v", err)
+	}
+
+	result.Imported = len(result.VehicleIDs)
+	return &result, http.StatusCreated, nil
+}
+
+// importVehicle registers an imported vehicle. A rejected registration of the
+// plate is handled as RegisterVehicle does: the same owner's record is
+// reopened, anyone else's is archived.
+func importVehicle(tx *gorm.DB, actor models.AuditActor, rejected *models.Vehicle, vehicle *models.Vehicle) (models.Vehicle, error) {
+	if rejected == nil {
+		return createVehicle(tx, actor, AuditVehicleImported, vehicle)
+	}
+
+	if rejected.UserID != vehicle.UserID {
+		if err := archiveRejectedVehicle(tx, actor, *rejected); err != nil {
+			return models.Vehicle{}, err
+		}
+		return createVehicle(tx, actor, AuditVehicleImported, vehicle)
+	}
+
+	var reopened models.Vehicle
+	if err := reopenRejectedVehicle(tx, *rejected, vehicle); err != nil {
+		return reopened, err
+	}
+	if err := tx.First(&reopened, "id = ?", rejected.ID).Error; err != nil {
+		return reopened, err
+	}
+	return reopened, recordAudit(tx, actor, AuditVehicleImported, AuditTargetVehicle, reopened.ID, *rejected, reopened)
+}
+
+// readVehicleImport reads the rows of an import file. Columns are matched by
+// their header, so they may come in any order.
+func readVehicleImport(r io.Reader) ([]vehicleImportRow, int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, http.StatusBadRequest, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid CSV: %v", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// spreadsheets often save UTF-8 with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range models.VehicleCSVHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, http.StatusBadRequest, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	maxRows := vehicleImportMaxRows()
+	var rows []vehicleImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid CSV: %v", err)
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(unescapeCSVCell(strings.TrimSpace(record[i])))
+			}
+			return ""
+		}
+		if len(rows) == maxRows {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("file has more than %d vehicles", maxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, vehicleImportRow{
+			line:       line,
+			ownerEmail: strings.ToLower(field("owner_email")),
+			vehicle: models.Vehicle{
+				PlateNumber: field("plate_number"),
+				Model:       field("model"),
+				Color:       field("color"),
+				Type:        strings.ToLower(field("type")),
+			},
+		})
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusBadRequest, errors.New("file has no vehicles")
+	}
+
+	return rows, http.StatusOK, nil
+}
+
+// loadVehicleImportLookup fetches the owners, registered plates and vehicle
+// types the rows refer to.
+func loadVehicleImportLookup(db *gorm.DB, rows []vehicleImportRow) (vehicleImportLookup, error) {
+	lookup := vehicleImportLookup{
+		owners:       map[string]models.User{},
+		vehicles:     map[string]models.Vehicle{},
+		vehicleTypes: map[string]bool{},
+	}
+
+	var emails, normalizedPlates []string
+	for _, row := range rows {
+		if row.ownerEmail != "" {
+			emails = append(emails, row.ownerEmail)
+		}
+		if normalized := utility.NormalizePlateNumber(row.vehicle.PlateNumber); normalized != "" {
+			normalizedPlates = append(normalizedPlates, normalized)
+		}
+	}
+
+	if len(emails) > 0 {
+		var users []models.User
+		if err := db.Where("LOWER(email) IN ?", emails).Find(&users).Error; err != nil {
+			return lookup, err
+		}
+		for _, user := range users {
+			lookup.owners[strings.ToLower(user.Email)] = user
+		}
+	}
+
+	if len(normalizedPlates) > 0 {
+		var vehicles []models.Vehicle
+		if err := db.Where("normalized_plate IN ?", normalizedPlates).Find(&vehicles).Error; err != nil {
+			return lookup, err
+		}
+		for _, vehicle := range vehicles {
+			lookup.vehicles[vehicle.NormalizedPlate] = vehicle
+		}
+	}
+
+	var codes []string
+	if err := db.Model(&models.VehicleType{}).Where("active = ?", true).Pluck("code", &codes).Error; err != nil {
+		return lookup, err
+	}
+	for _, code := range codes {
+		lookup.vehicleTypes[code] = true
+	}
+
+	return lookup, nil
+}
+
+// checkVehicleImportRows records each row's problems on it and fills in the
+// owner and plate details of the rows that can be imported. Plates follow
+// RegisterVehicle: only a rejected registration may be registered again.
+func checkVehicleImportRows(rows []vehicleImportRow, lookup vehicleImportLookup) {
+	seen := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+
+		if row.ownerEmail == "" {
+			row.addError("owner_email is required")
+		} else if owner, ok := lookup.owners[row.ownerEmail]; !ok {
+			row.addError("no user with email %s", row.ownerEmail)
+		} else if owner.IsSuspended() {
+			row.addError("user %s cannot receive vehicles", row.ownerEmail)
+		} else {
+			row.vehicle.UserID = owner.ID
+		}
+
+		if row.vehicle.PlateNumber == "" {
+			row.addError("plate_number is required")
+		} else if plate, err := ParsePlateNumber(row.vehicle.PlateNumber); err != nil {
+			row.addError("%v", err)
+		} else {
+			row.plate = plate.Normalized
+			row.vehicle.PlateCountry = plate.Country
+			row.vehicle.PlateCategory = plate.Category
+
+			if existing, ok := lookup.vehicles[row.plate]; ok {
+				if existing.Status == models.VehicleStatusRejected {
+					row.rejected = &existing
+				} else {
+					row.addError("plate number %s is already registered", row.vehicle.PlateNumber)
+				}
+			}
+			if line, ok := seen[row.plate]; ok {
+				row.addError("plate number %s duplicates row %d", row.vehicle.PlateNumber, line)
+			} else {
+				seen[row.plate] = row.line
+			}
+		}
+
+		if row.vehicle.Model == "" {
+			row.addError("model is required")
+		}
+		if row.vehicle.Color == "" {
+			row.addError("color is required")
+		}
+		if row.vehicle.Type == "" {
+			row.addError("type is required")
+		} else if !lookup.vehicleTypes[row.vehicle.Type] {
+			row.addError("unknown vehicle type %s", row.vehicle.Type)
+		}
+	}
+}
+
+// ExportVehicles writes active vehicles with the given review status as CSV
+// in the import layout, leaving archived ones out. The status defaults to
+// approved, so an export imports back without rows failing as already
+// registered.
+func ExportVehicles(db *gorm.DB, w io.Writer, status string) error {
+	if status == "" {
+		status = models.VehicleStatusApproved
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(models.VehicleCSVHeader); err != nil {
+		return err
+	}
+
+	query := db.Model(&models.Vehicle{}).
+		Select(`users.email,
+			COALESCE(vehicles.plate_number, ''),
+			COALESCE(vehicles.model, ''),
+			COALESCE(vehicles.color, ''),
+			COALESCE(vehicles.type, '')`).
+		Joins("JOIN users ON users.id = vehicles.user_id").
+		Where("vehicles.status = ?", status)
+
+	rows, err := query.Order("vehicles.plate_number").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	record := make([]string, len(models.VehicleCSVHeader))
+	for rows.Next() {
+		if err := rows.Scan(&record[0], &record[1], &record[2], &record[3], &record[4]); err != nil {
+			return err
+		}
+		for i := range record {
+			record[i] = escapeCSVCell(record[i])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvFormulaPrefixes start a cell that spreadsheets evaluate as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// escapeCSVCell quotes a cell spreadsheets would run as a formula by
+// prefixing it with an apostrophe, which they hide.
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVCell undoes escapeCSVCell, so an exported file imports as it
+// was exported.
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
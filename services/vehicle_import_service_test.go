@@ -0,0 +1,230 @@
+package services
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"survielx-backend/models"
+	"testing"
+	"time"
+)
+
+func TestReadVehicleImport(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []vehicleImportRow
+		code  int
+	}{
+		{
+			name:  "columns in any order",
+			input: "\ufeffPlate_Number,owner_email,type,color,model\nABC-123 DE, Ada@Example.com ,CAR,Blue,Corolla\n",
+			want: []vehicleImportRow{{
+				line:       2,
+				ownerEmail: "ada@example.com",
+				vehicle:    models.Vehicle{PlateNumber: "ABC-123 DE", Model: "Corolla", Color: "Blue", Type: "car"},
+			}},
+			code: http.StatusOK,
+		},
+		{
+			name:  "blank lines skipped",
+			input: "owner_email,plate_number,model,color,type\n,,,,\na@example.com,ABC123DE,Corolla,Blue,car\n",
+			want: []vehicleImportRow{{
+				line:       3,
+				ownerEmail: "a@example.com",
+				vehicle:    models.Vehicle{PlateNumber: "ABC123DE", Model: "Corolla", Color: "Blue", Type: "car"},
+			}},
+			code: http.StatusOK,
+		},
+		{
+			name:  "escaped formulas unescaped",
+			input: "owner_email,plate_number,model,color,type\na@example.com,ABC123DE,'=Corolla,'-Blue,car\n",
+			want: []vehicleImportRow{{
+				line:       2,
+				ownerEmail: "a@example.com",
+				vehicle:    models.Vehicle{PlateNumber: "ABC123DE", Model: "=Corolla", Color: "-Blue", Type: "car"},
+			}},
+			code: http.StatusOK,
+		},
+		{
+			name:  "short record",
+			input: "owner_email,plate_number,model,color,type\na@example.com,ABC123DE\n",
+			want: []vehicleImportRow{{
+				line:       2,
+				ownerEmail: "a@example.com",
+				vehicle:    models.Vehicle{PlateNumber: "ABC123DE"},
+			}},
+			code: http.StatusOK,
+		},
+		{name: "empty file", input: "", code: http.StatusBadRequest},
+		{name: "missing column", input: "owner_email,plate_number,model,color\n", code: http.StatusBadRequest},
+		{name: "no vehicles", input: "owner_email,plate_number,model,color,type\n\n", code: http.StatusBadRequest},
+		{name: "invalid quoting", input: "owner_email,plate_number,model,color,type\n\"a,b\n", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, code, err := readVehicleImport(strings.NewReader(tt.input))
+			if code != tt.code {
+				t.Fatalf("code = %d, want %d (err %v)", code, tt.code, err)
+			}
+			if tt.code != http.StatusOK {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("rows = %+v, want %+v", rows, tt.want)
+			}
+			for i := range rows {
+				got, want := rows[i], tt.want[i]
+				if got.line != want.line || got.ownerEmail != want.ownerEmail || got.vehicle != want.vehicle {
+					t.Errorf("row %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadVehicleImportMaxRows(t *testing.T) {
+	t.Setenv("VEHICLE_IMPORT_MAX_ROWS", "1")
+
+	input := "owner_email,plate_number,model,color,type\na@example.com,ABC123DE,,,\nb@example.com,ABC124DE,,,\n"
+	if _, code, _ := readVehicleImport(strings.NewReader(input)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("code = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestCheckVehicleImportRows(t *testing.T) {
+	defer func(countries []string) { acceptedPlateCountries = countries }(acceptedPlateCountries)
+	acceptedPlateCountries = []string{"NG"}
+
+	suspendedAt := time.Now()
+	lookup := vehicleImportLookup{
+		owners: map[string]models.User{
+			"ada@example.com":  {ID: "ada"},
+			"bola@example.com": {ID: "bola", SuspendedAt: &suspendedAt},
+		},
+		vehicles: map[string]models.Vehicle{
+			"ABC123DE": {ID: "approved", UserID: "ada", NormalizedPlate: "ABC123DE", Status: models.VehicleStatusApproved},
+			"ABC124DE": {ID: "pending", UserID: "ada", NormalizedPlate: "ABC124DE", Status: models.VehicleStatusPendingReview},
+			"ABC125DE": {ID: "rejected", UserID: "chidi", NormalizedPlate: "ABC125DE", Status: models.VehicleStatusRejected},
+		},
+		vehicleTypes: map[string]bool{"car": true},
+	}
+	row := func(email, plate string) vehicleImportRow {
+		return vehicleImportRow{
+			ownerEmail: email,
+			vehicle:    models.Vehicle{PlateNumber: plate, Model: "Corolla", Color: "Blue", Type: "car"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		row      vehicleImportRow
+		errors   []string
+		rejected string
+	}{
+		{name: "valid", row: row("ada@example.com", "ABC 126 DE")},
+		{name: "rejected plate registered again", row: row("ada@example.com", "abc-125-de"), rejected: "rejected"},
+		{name: "approved plate", row: row("ada@example.com", "ABC123DE"), errors: []string{"plate number ABC123DE is already registered"}},
+		{name: "pending plate", row: row("ada@example.com", "ABC124DE"), errors: []string{"plate number ABC124DE is already registered"}},
+		{name: "unknown owner", row: row("eze@example.com", "ABC126DE"), errors: []string{"no user with email eze@example.com"}},
+		{name: "suspended owner", row: row("bola@example.com", "ABC126DE"), errors: []string{"user bola@example.com cannot receive vehicles"}},
+		{
+			name: "missing fields",
+			row:  vehicleImportRow{vehicle: models.Vehicle{PlateNumber: "ABC126DE"}},
+			errors: []string{
+				"owner_email is required",
+				"model is required",
+				"color is required",
+				"type is required",
+			},
+		},
+		{
+			name: "unknown type",
+			row: vehicleImportRow{
+				ownerEmail: "ada@example.com",
+				vehicle:    models.Vehicle{PlateNumber: "ABC126DE", Model: "Corolla", Color: "Blue", Type: "boat"},
+			},
+			errors: []string{"unknown vehicle type boat"},
+		},
+		{name: "missing plate", row: row("ada@example.com", ""), errors: []string{"plate_number is required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := []vehicleImportRow{tt.row}
+			checkVehicleImportRows(rows, lookup)
+
+			got := rows[0]
+			if !slices.Equal(got.errors, tt.errors) {
+				t.Errorf("errors = %q, want %q", got.errors, tt.errors)
+			}
+			var rejected string
+			if got.rejected != nil {
+				rejected = got.rejected.ID
+			}
+			if rejected != tt.rejected {
+				t.Errorf("rejected = %q, want %q", rejected, tt.rejected)
+			}
+			if len(tt.errors) == 0 && (got.vehicle.UserID != "ada" || got.vehicle.PlateCountry != "NG") {
+				t.Errorf("vehicle = %+v, want owner and country filled in", got.vehicle)
+			}
+		})
+	}
+}
+
+func TestCheckVehicleImportRowsDuplicates(t *testing.T) {
+	defer func(countries []string) { acceptedPlateCountries = countries }(acceptedPlateCountries)
+	acceptedPlateCountries = []string{"NG"}
+
+	lookup := vehicleImportLookup{
+		owners:       map[string]models.User{"ada@example.com": {ID: "ada"}},
+		vehicles:     map[string]models.Vehicle{},
+		vehicleTypes: map[string]bool{"car": true},
+	}
+	rows := []vehicleImportRow{
+		{line: 2, ownerEmail: "ada@example.com", vehicle: models.Vehicle{PlateNumber: "ABC123DE", Model: "Corolla", Color: "Blue", Type: "car"}},
+		{line: 3, ownerEmail: "ada@example.com", vehicle: models.Vehicle{PlateNumber: "abc 123 de", Model: "Corolla", Color: "Blue", Type: "car"}},
+	}
+	checkVehicleImportRows(rows, lookup)
+
+	if len(rows[0].errors) != 0 {
+		t.Errorf("first row errors = %q", rows[0].errors)
+	}
+	want := []string{"plate number abc 123 de duplicates row 2"}
+	if !slices.Equal(rows[1].errors, want) {
+		t.Errorf("second row errors = %q, want %q", rows[1].errors, want)
+	}
+}
+
+func TestCSVCellEscaping(t *testing.T) {
+	tests := []struct {
+		value   string
+		escaped string
+	}{
+		{"Corolla", "Corolla"},
+		{"", ""},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+234", "'+234"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"'quoted", "'quoted"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			escaped := escapeCSVCell(tt.value)
+			if escaped != tt.escaped {
+				t.Errorf("escapeCSVCell(%q) = %q, want %q", tt.value, escaped, tt.escaped)
+			}
+			if got := unescapeCSVCell(escaped); got != tt.value {
+				t.Errorf("unescapeCSVCell(%q) = %q, want %q", escaped, got, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"survielx-backend/connections"
+	"survielx-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetVehicleReviewQueue lists registrations in the given review status,
+// oldest first. It defaults to those waiting for review.
+func GetVehicleReviewQueue(db *gorm.DB, status string, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		vehicles []models.Vehicle
+		count    int64
+	)
+
+	if status == "" {
+		status = models.VehicleStatusPendingReview
+	}
+
+	query := db.Model(&models.Vehicle{}).Where("status = ?", status)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count vehicles: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Order("created_at asc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&vehicles).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch vehicles: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: vehicles,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(vehicles),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
+
+// ReviewVehicle approves or rejects a registration waiting for review and
+// lets the owner know. Rejections must give a reason.
+func ReviewVehicle(db *gorm.DB, actor models.AuditActor, vehicleID string, input models.ReviewVehicleInput) (*models.Vehicle, int, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if !input.Approve && reason == "" {
+		return nil, http.StatusBadRequest, errors.New("a reason is required to reject a registration")
+	}
+
+	var (
+		vehicle models.Vehicle
+		code    = http.StatusOK
+	)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, "id = ?", vehicleID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+				return errors.New("vehicle does not exist")
+			}
+			code = http.StatusInternalServerError
+			return err
+		}
+		if vehicle.Status != models.VehicleStatusPendingReview {
+			code = http.StatusBadRequest
+			return errors.New("vehicle is not waiting for review")
+		}
+
+		before := vehicle
+		now := time.Now()
+		action := AuditVehicleApproved
+		vehicle.Status = models.VehicleStatusApproved
+		if !input.Approve {
+			action = AuditVehicleRejected
+			vehicle.Status = models.VehicleStatusRejected
+		}
+		vehicle.ReviewedByID = &actor.ID
+		vehicle.ReviewedAt = &now
+		vehicle.ReviewReason = reason
+
+		err = tx.Model(&vehicle).Updates(map[string]any{
+			"status":         vehicle.Status,
+			"reviewed_by_id": actor.ID,
+			"reviewed_at":    now,
+			"review_reason":  reason,
+		}).Error
+		if err != nil {
+			code = http.StatusInternalServerError
+			return err
+		}
+		return recordAudit(tx, actor, action, AuditTargetVehicle, vehicle.ID, before, vehicle)
+	})
+	if err != nil {
+		return nil, code, err
+	}
+
+	go notifyVehicleReviewed(vehicle)
+
+	return &vehicle, http.StatusOK, nil
+}
+
+// notifyVehicleReviewed tells the owner's open sessions how their
+// registration was reviewed.
+func notifyVehicleReviewed(vehicle models.Vehicle) {
+	clients := connections.GetClients(vehicle.UserID)
+	if len(clients) == 0 {
+		log.Println("User not connected:", vehicle.UserID)
+		return
+	}
+
+	msg := map[string]any{
+		"type":         "vehicle_review",
+		"vehicle_id":   vehicle.ID,
+		"plate_number": vehicle.PlateNumber,
+		"status":       vehicle.Status,
+		"reason":       vehicle.ReviewReason,
+	}
+	for _, client := range clients {
+		if err := client.WriteJSON(msg); err != nil {
+			log.Println("WS write error:", err)
+		}
+	}
+}
@@ -14,76 +14,166 @@ import (
 	"survielx-backend/utility"
 )
 
-func RegisterVehicle(vehicle *models.Vehicle) (*models.Vehicle, int, error) {
+// RegisterVehicle records a vehicle for review by security. Until it is
+// approved the vehicle is treated as a guest at the gate. A plate whose
+// registration was rejected may be registered again: by the same user it goes
+// back into review, by anyone else the rejected record is archived and a new
+// one started. The plate of an archived vehicle starts a new record.
+func RegisterVehicle(actor models.AuditActor, vehicle *models.Vehicle) (*models.Vehicle, int, error) {
 	db := database.DB
 
-	if !IsValidNigerianPlate(vehicle.PlateNumber) {
-		return nil, http.StatusBadRequest, errors.New("not a valid Nigerian plate number")
+	plate, err := ParsePlateNumber(vehicle.PlateNumber)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
 	}
+	vehicle.PlateCountry = plate.Country
+	vehicle.PlateCategory = plate.Category
+	vehicle.Status = models.VehicleStatusPendingReview
 
-	checkExists := models.CheckExists(db, &models.Vehicle{}, "plate_number = ?", vehicle.PlateNumber)
-	if checkExists {
+	var existing models.Vehicle
+	checkExists := models.CheckExists(db, &existing, "normalized_plate = ?", plate.Normalized)
+	if checkExists && existing.Status != models.VehicleStatusRejected {
 		return nil, http.StatusConflict, fmt.Errorf("vehicle with plate number %s already exists", vehicle.PlateNumber)
 	}
 
-	if err := db.Create(vehicle).Error; err != nil {
-		return nil, http.StatusBadRequest, err
+	if checkExists && existing.UserID == vehicle.UserID {
+		return resubmitVehicle(db, actor, existing, vehicle)
 	}
 
 	var fullVehicle models.Vehicle
-	if err := db.
-		First(&fullVehicle, "id = ?", vehicle.ID).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if checkExists {
+			if err := archiveRejectedVehicle(tx, actor, existing); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		fullVehicle, err = createVehicle(tx, actor, AuditVehicleRegistered, vehicle)
+		return err
+	})
+	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
 
 	return &fullVehicle, http.StatusCreated, nil
 }
 
-func UpdateVehicle(db *gorm.DB, vehicle_id string, input models.UpdateVehicleInput) (*models.Vehicle, int, error) {
-	var vehicle models.Vehicle
-
-	exists := models.CheckExists(db, &vehicle, "id = ?", vehicle_id)
-	if !exists {
-		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+// createVehicle stores a new vehicle with its owner as the first owner and
+// driver, and records it under the given audit action.
+func createVehicle(tx *gorm.DB, actor models.AuditActor, action string, vehicle *models.Vehicle) (models.Vehicle, error) {
+	var created models.Vehicle
+	if err := tx.Create(vehicle).Error; err != nil {
+		return created, err
+	}
+	if err := ensureVehicleOwnership(tx, *vehicle); err != nil {
+		return created, err
+	}
+	if err := ensureVehicleOwnerDriver(tx, *vehicle); err != nil {
+		return created, err
 	}
 
-	tx := db.Model(&vehicle).Updates(input)
-	if tx.Error != nil {
-		return nil, http.StatusNotFound, tx.Error
+	if err := tx.First(&created, "id = ?", vehicle.ID).Error; err != nil {
+		return created, err
 	}
+	return created, recordAudit(tx, actor, action, AuditTargetVehicle, created.ID, nil, created)
+}
+
+// resubmitVehicle puts the registrant's own rejected registration back into
+// review with the new details, keeping its ID so the rejection stays on
+// record.
+func resubmitVehicle(db *gorm.DB, actor models.AuditActor, rejected models.Vehicle, vehicle *models.Vehicle) (*models.Vehicle, int, error) {
+	var updated models.Vehicle
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := reopenRejectedVehicle(tx, rejected, vehicle); err != nil {
+			return err
+		}
 
-	if tx.RowsAffected == 0 {
-		return nil, http.StatusBadRequest, errors.New("no changes made to the vehicle")
+		if err := tx.First(&updated, "id = ?", rejected.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleRegistered, AuditTargetVehicle, updated.ID, rejected, updated)
+	})
+	if err != nil {
+		return nil, http.StatusBadRequest, err
 	}
 
-	return &vehicle, http.StatusOK, nil
+	return &updated, http.StatusCreated, nil
+}
+
+// reopenRejectedVehicle replaces a rejected registration's details and review
+// with those of the vehicle registered in its place.
+func reopenRejectedVehicle(tx *gorm.DB, rejected models.Vehicle, vehicle *models.Vehicle) error {
+	return tx.Model(&rejected).Updates(map[string]any{
+		"plate_number":   vehicle.PlateNumber,
+		"plate_country":  vehicle.PlateCountry,
+		"plate_category": vehicle.PlateCategory,
+		"type":           vehicle.Type,
+		"model":          vehicle.Model,
+		"color":          vehicle.Color,
+		"status":         vehicle.Status,
+		"reviewed_by_id": vehicle.ReviewedByID,
+		"reviewed_at":    vehicle.ReviewedAt,
+		"review_reason":  vehicle.ReviewReason,
+	}).Error
+}
+
+// archiveRejectedVehicle retires a rejected registration whose plate another
+// user is registering, so its owner, drivers and history stay with the old
+// record.
+func archiveRejectedVehicle(tx *gorm.DB, actor models.AuditActor, rejected models.Vehicle) error {
+	before := rejected
+	if err := archiveVehicle(tx, &rejected, actor.ID, "plate registered again by another user", time.Now()); err != nil {
+		return err
+	}
+	return recordAudit(tx, actor, AuditVehicleDeregistered, AuditTargetVehicle, rejected.ID, before, rejected)
 }
 
-func DeRegisterVehicle(db *gorm.DB, vehicle_id string) (int, error) {
+func UpdateVehicle(db *gorm.DB, actor models.AuditActor, vehicle_id string, input models.UpdateVehicleInput) (*models.Vehicle, int, error) {
 	var vehicle models.Vehicle
 
 	exists := models.CheckExists(db, &vehicle, "id = ?", vehicle_id)
 	if !exists {
-		return http.StatusNotFound, errors.New("vehicle does not exist")
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
 	}
 
-	err := vehicle.DeRegister(db)
+	before := vehicle
+
+	code := http.StatusNotFound
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&vehicle).Updates(input)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			code = http.StatusBadRequest
+			return errors.New("no changes made to the vehicle")
+		}
+
+		code = http.StatusInternalServerError
+		var after models.Vehicle
+		if err := tx.First(&after, "id = ?", vehicle.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleUpdated, AuditTargetVehicle, vehicle.ID, before, after)
+	})
 	if err != nil {
-		return http.StatusBadRequest, err
+		return nil, code, err
 	}
 
-	return http.StatusOK, nil
+	return &vehicle, http.StatusOK, nil
 }
 
 func GetVehicleByPlateNumber(plateNumber string) (*models.Vehicle, int, error) {
 	var vehicle models.Vehicle
-	if err := database.DB.Where("plate_number = ?", plateNumber).First(&vehicle).Error; err != nil {
+	if err := database.DB.Where("normalized_plate = ?", utility.NormalizePlateNumber(plateNumber)).First(&vehicle).Error; err != nil {
 		return nil, http.StatusNotFound, fmt.Errorf("failed to fetch plate number: %v", err)
 	}
 	return &vehicle, http.StatusOK, nil
 }
 
-func LogVehicleActivity(db *gorm.DB, req models.LogVehicleActivityInput) (int, error) {
+func LogVehicleActivity(db *gorm.DB, actor models.AuditActor, req models.LogVehicleActivityInput) (int, error) {
 
 	activity := models.VehicleActivity{
 		PlateNumber: req.PlateNumber,
@@ -125,6 +215,9 @@ func LogVehicleActivity(db *gorm.DB, req models.LogVehicleActivityInput) (int, e
 	if err != nil {
 		return http.StatusNotFound, fmt.Errorf("registered vehicle not found: %v", err)
 	}
+	if !vehicle.IsApproved() {
+		return http.StatusConflict, fmt.Errorf("vehicle %s is not an approved registration (%s); log it as a guest", vehicle.PlateNumber, vehicle.Status)
+	}
 
 	activity.VehicleID = &vehicle.ID
 	activity.VehicleType = vehicle.Type
@@ -135,32 +228,46 @@ func LogVehicleActivity(db *gorm.DB, req models.LogVehicleActivityInput) (int, e
 	}
 
 	if req.IsEntry {
-		return HandleEntryProcedures(db, activity)
+		return HandleEntryProcedures(db, actor, activity)
 	}
 
-	return HandleExitProcedures(db, activity, vehicle)
+	return HandleExitProcedures(db, actor, activity, vehicle)
 }
 
-func HandleEntryProcedures(db *gorm.DB, activity models.VehicleActivity) (int, error) {
-	if err := db.Create(&activity).Error; err != nil {
-		fmt.Printf("failed to create vehicle activity log: %v\n", err)
-		return http.StatusBadRequest, err
-	}
+func HandleEntryProcedures(db *gorm.DB, actor models.AuditActor, activity models.VehicleActivity) (int, error) {
+	code := http.StatusBadRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&activity).Error; err != nil {
+			fmt.Printf("failed to create vehicle activity log: %v\n", err)
+			return err
+		}
 
-	var pendingExit models.PendingVehicleExit
-	err := db.Where("plate_number = ? AND status = ?", activity.PlateNumber, "pending").First(&pendingExit).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		fmt.Printf("error checking pending exit requests: %v\n", err)
-		return http.StatusInternalServerError, err
-	}
+		code = http.StatusInternalServerError
+		if err := recordAudit(tx, actor, AuditVehicleActivityLogged, AuditTargetVehicleActivity, activity.ID, nil, activity); err != nil {
+			return err
+		}
+
+		var pendingExit models.PendingVehicleExit
+		err := tx.Where("vehicle_id = ? AND status = ?", *activity.VehicleID, "pending").First(&pendingExit).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			fmt.Printf("error checking pending exit requests: %v\n", err)
+			return err
+		}
 
-	if err == nil {
+		before := pendingExit
 		pendingExit.Status = "approved"
-		if err := db.Save(&pendingExit).Error; err != nil {
+		if err := tx.Save(&pendingExit).Error; err != nil {
 			fmt.Printf("failed to update pending exit request: %v\n", err)
-			return http.StatusInternalServerError, err
+			return err
 		}
 		fmt.Printf("pending exit request for vehicle %s approved upon entry\n", activity.PlateNumber)
+		return recordAudit(tx, actor, AuditPendingExitResolved, AuditTargetPendingExit, pendingExit.ID, before, pendingExit)
+	})
+	if err != nil {
+		return code, err
 	}
 
 	// err = validateVehicleEntryExit(db, *activity.VehicleID, true)
@@ -171,7 +278,7 @@ func HandleEntryProcedures(db *gorm.DB, activity models.VehicleActivity) (int, e
 	return http.StatusCreated, nil
 }
 
-func HandleExitProcedures(db *gorm.DB, activity models.VehicleActivity, vehicle *models.Vehicle) (int, error) {
+func HandleExitProcedures(db *gorm.DB, actor models.AuditActor, activity models.VehicleActivity, vehicle *models.Vehicle) (int, error) {
 	responseToken := utility.GenerateUUID()
 	pending := models.PendingVehicleExit{
 		ID:            utility.GenerateUUID(),
@@ -184,18 +291,54 @@ func HandleExitProcedures(db *gorm.DB, activity models.VehicleActivity, vehicle
 		ResponseToken: responseToken,
 	}
 
-	if err := db.Create(&pending).Error; err != nil {
+	driverIDs, err := authorizedDriverIDs(db, *vehicle)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to fetch vehicle drivers: %v", err)
+	}
+
+	var drivers []models.User
+	if err := db.Where("id IN ?", driverIDs).Find(&drivers).Error; err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to fetch vehicle drivers: %v", err)
+	}
+
+	var recipients []string
+	for i := range drivers {
+		if canReceiveExitConfirmation(&drivers[i]) {
+			recipients = append(recipients, drivers[i].ID)
+		}
+	}
+
+	if len(recipients) == 0 {
+		// no driver can confirm, so security decides straight away
+		pending.Status = "unconfirmable"
+		if err := createPendingExit(db, actor, &pending); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to create pending exit: %v", err)
+		}
+		go notifySecurity(pending.PlateNumber, pending.Timestamp, pending.ExitPointID)
+		return http.StatusAccepted, nil
+	}
+
+	if err := createPendingExit(db, actor, &pending); err != nil {
 		return http.StatusInternalServerError, fmt.Errorf("failed to create pending exit: %v", err)
 	}
 
-	//trigger notification to vehicle owner for exit approval
-	go notifyUserForExitConfirmation(pending.ID, vehicle.UserID, responseToken)
+	// every authorized driver is asked; the first to answer decides
+	go notifyUserForExitConfirmation(pending.ID, recipients, responseToken)
 
 	go handleExitTimeout(pending.ID, db, pending.ExitPointID)
 
 	return http.StatusAccepted, nil
 }
 
+func createPendingExit(db *gorm.DB, actor models.AuditActor, pending *models.PendingVehicleExit) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pending).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditPendingExitCreated, AuditTargetPendingExit, pending.ID, nil, pending)
+	})
+}
+
 func GetVehicleLogs(userId string) (*[]models.VehicleActivity, int, error) {
 	var logs []models.VehicleActivity
 	if err := database.DB.Where("user_id = ?", userId).Find(&logs).Error; err != nil {
@@ -240,14 +383,54 @@ func CreateVehicleLog(vehicle *models.Vehicle, req models.LogVehicleInput) (*mod
 }
 
 // the model backend calls this to find out if the vehicle exists either as a registered user or guest user
-func IdentifyVehicle(plateNumber string) (models.VehicleIdentity, int, error) {
-	vehicle, statuscode, err := GetVehicleByPlateNumber(plateNumber)
+func IdentifyVehicle(plateNumber string, fuzzy bool) (models.VehicleIdentity, int, error) {
+	if !fuzzy {
+		vehicle, statuscode, err := GetVehicleByPlateNumber(plateNumber)
+		if err != nil {
+			// if not record found...security personnel should log this vehicle entry as a guest entry
+			return models.VehicleIdentity{MatchType: PlateMatchNone}, statuscode, err
+		}
+		if !vehicle.IsApproved() {
+			// registrations still in review are guests until security approves them
+			return models.VehicleIdentity{MatchType: PlateMatchNone}, http.StatusNotFound, errors.New("vehicle registration has not been approved")
+		}
+
+		identity, code, err := GetVehicleStatus(vehicle.ID)
+		identity.MatchType = PlateMatchExact
+		identity.Score = 1
+		identity.VehicleID = vehicle.ID
+		identity.MatchedPlateNumber = vehicle.PlateNumber
+		return identity, code, err
+	}
+
+	candidates, err := MatchVehicles(database.DB, plateNumber, utility.GetEnvInt("PLATE_FUZZY_MAX_CANDIDATES", 5))
 	if err != nil {
-		// if not record found...security personnel should log this vehicle entry as a guest entry
-		return models.VehicleIdentity{}, statuscode, err
+		return models.VehicleIdentity{}, http.StatusInternalServerError, fmt.Errorf("failed to match plate number: %v", err)
+	}
+	if len(candidates) == 0 {
+		return models.VehicleIdentity{MatchType: PlateMatchNone}, http.StatusNotFound, errors.New("no registered vehicle matches this plate number")
+	}
+
+	accepted := acceptedCandidate(candidates)
+	if accepted == nil {
+		// a person has to pick one of the candidates or log the vehicle as a guest
+		return models.VehicleIdentity{
+			Status:     "unknown",
+			MatchType:  PlateMatchFuzzy,
+			Score:      candidates[0].Score,
+			Candidates: candidates,
+		}, http.StatusOK, nil
 	}
 
-	return GetVehicleStatus(vehicle.ID)
+	identity, code, err := GetVehicleStatus(accepted.VehicleID)
+	identity.MatchType = accepted.MatchType
+	identity.Score = accepted.Score
+	identity.VehicleID = accepted.VehicleID
+	identity.MatchedPlateNumber = accepted.PlateNumber
+	if accepted.MatchType == PlateMatchFuzzy {
+		identity.Candidates = candidates
+	}
+	return identity, code, err
 }
 
 func GetVehicleStatus(vehicleID string) (models.VehicleIdentity, int, error) {
@@ -260,7 +443,7 @@ func GetVehicleStatus(vehicleID string) (models.VehicleIdentity, int, error) {
 
 	vehicleIdentity.Status = "outside"
 	exists := models.CheckExists(db, &vehicle, "id = ?", vehicleID)
-	if exists {
+	if exists && vehicle.IsApproved() {
 		vehicleIdentity.IsRegistered = true
 	}
 
@@ -344,7 +527,8 @@ func GetVehicleActivities(db *gorm.DB, vehicle_id string, pagination models.Pagi
 	var responses []models.VehicleActivityResponse
 	var count int64
 
-	exists := models.CheckExists(db, &models.Vehicle{}, "id = ?", vehicle_id)
+	// archived vehicles keep their history
+	exists := models.CheckExists(db.Unscoped(), &models.Vehicle{}, "id = ?", vehicle_id)
 	if !exists {
 		return nil, http.StatusNotFound, fmt.Errorf("vehicle with ID %s not found", vehicle_id)
 	}
@@ -458,7 +642,7 @@ func GetGuestVehicleActivitiesByPlateNumber(db *gorm.DB, plateNumber string, pag
 	var count int64
 
 	query := db.Model(&models.VehicleActivity{}).
-		Where("vehicle_activities.plate_number = ? AND vehicle_activities.visitor_type = ?", plateNumber, models.VisitorTypeGuest)
+		Where("vehicle_activities.normalized_plate = ? AND vehicle_activities.visitor_type = ?", utility.NormalizePlateNumber(plateNumber), models.VisitorTypeGuest)
 
 	if err := query.Count(&count).Error; err != nil {
 		return nil, http.StatusBadRequest, fmt.Errorf("failed to count guest vehicle activities: %v", err)
@@ -521,7 +705,7 @@ func convertToActivityResponse(activity models.VehicleActivity) models.VehicleAc
 func validateGuestEntryExit(db *gorm.DB, plateNumber string, isEntry bool) error {
 	var lastActivity models.VehicleActivity
 
-	err := db.Where("plate_number = ? AND visitor_type = ?", plateNumber, models.VisitorTypeGuest).
+	err := db.Where("normalized_plate = ? AND visitor_type = ?", utility.NormalizePlateNumber(plateNumber), models.VisitorTypeGuest).
 		Order("timestamp desc").
 		First(&lastActivity).Error
 
@@ -552,7 +736,7 @@ func GetAllVehicleActivities(from, to time.Time, visitorType *models.VisitorType
 	db := database.DB
 	var activities []models.VehicleActivity
 
-	query := db.Preload("Vehicle").
+	query := db.Preload("Vehicle", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
 		Preload("EntryPoint").
 		Preload("ExitPoint").
 		Where("timestamp BETWEEN ? AND ?", from, to)
@@ -575,7 +759,14 @@ func GetAllVehicleActivities(from, to time.Time, visitorType *models.VisitorType
 	return responses, nil
 }
 
-func GenerateActivitySummary(activities []models.VehicleActivityResponse) map[string]any {
+// GenerateActivitySummary aggregates activities for a report. Vehicle types
+// are broken down by the given catalog codes.
+func GenerateActivitySummary(activities []models.VehicleActivityResponse, vehicleTypes []string) map[string]any {
+	byVehicleType := make(map[string]int, len(vehicleTypes))
+	for _, code := range vehicleTypes {
+		byVehicleType[code] = 0
+	}
+
 	summary := map[string]any{
 		"total_activities": len(activities),
 		"by_visitor_type": map[string]int{
@@ -586,11 +777,7 @@ func GenerateActivitySummary(activities []models.VehicleActivityResponse) map[st
 			"entries": 0,
 			"exits":   0,
 		},
-		"by_vehicle_type": map[string]int{
-			"car":  0,
-			"bike": 0,
-			"bus":  0,
-		},
+		"by_vehicle_type": byVehicleType,
 		"by_time_period": map[string]int{
 			"morning":   0, // 06:00 - 12:00
 			"afternoon": 0, // 12:00 - 18:00
@@ -636,7 +823,7 @@ func GenerateActivitySummary(activities []models.VehicleActivityResponse) map[st
 		}
 
 		// Track unique vehicles
-		summary["unique_vehicles"].(map[string]bool)[activity.PlateNumber] = true
+		summary["unique_vehicles"].(map[string]bool)[utility.NormalizePlateNumber(activity.PlateNumber)] = true
 
 		// Time period analysis
 		hour := activity.Timestamp.Hour()
@@ -692,7 +879,7 @@ func GenerateActivitySummary(activities []models.VehicleActivityResponse) map[st
 	return summary
 }
 
-func LogGuestVehicleActivity(db *gorm.DB, req models.LogVehicleActivityInput) (int, error) {
+func LogGuestVehicleActivity(db *gorm.DB, actor models.AuditActor, req models.LogVehicleActivityInput) (int, error) {
 	activity := models.GuestVehicleActivity{
 		PlateNumber: req.PlateNumber,
 		IsEntry:     req.IsEntry,
@@ -734,7 +921,13 @@ func LogGuestVehicleActivity(db *gorm.DB, req models.LogVehicleActivityInput) (i
 	// 	return http.StatusBadRequest, err
 	// }
 
-	if err := db.Create(&activity).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&activity).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditGuestActivityLogged, AuditTargetGuestActivity, activity.ID, nil, activity)
+	})
+	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("failed to create guest activity log: %v", err)
 	}
 
@@ -747,8 +940,8 @@ func FetchRegisteredVehiclesLogs(db *gorm.DB, pagination models.Pagination, filt
 
 	query := db.Model(&models.Vehicle{})
 
-	if filters.PlateNumber != "" {
-		query = query.Where("plate_number ILIKE ?", "%"+filters.PlateNumber+"%")
+	if plate := utility.NormalizePlateNumber(filters.PlateNumber); plate != "" {
+		query = query.Where("normalized_plate LIKE ?", "%"+plate+"%")
 	}
 	if filters.Model != "" {
 		query = query.Where("model ILIKE ?", "%"+filters.Model+"%")
@@ -791,8 +984,9 @@ func FetchGuestVehiclesLogs(db *gorm.DB, pagination models.Pagination, plateNumb
 
 	query := db.Model(&models.GuestVehicleActivity{})
 
+	plateNumber = utility.NormalizePlateNumber(plateNumber)
 	if plateNumber != "" {
-		query = query.Where("plate_number ILIKE ?", "%"+plateNumber+"%")
+		query = query.Where("normalized_plate LIKE ?", "%"+plateNumber+"%")
 	}
 
 	if err := query.Count(&count).Error; err != nil {
@@ -805,7 +999,7 @@ func FetchGuestVehiclesLogs(db *gorm.DB, pagination models.Pagination, plateNumb
 	if err := db.Model(&models.GuestVehicleActivity{}).
 		Scopes(func(d *gorm.DB) *gorm.DB {
 			if plateNumber != "" {
-				return d.Where("plate_number ILIKE ?", "%"+plateNumber+"%")
+				return d.Where("normalized_plate LIKE ?", "%"+plateNumber+"%")
 			}
 			return d
 		}).
@@ -835,7 +1029,7 @@ func GetVehicleOwnerProfile(db *gorm.DB, vehicleID string, pagination models.Pag
 	var user models.User
 	var profile models.Profile
 
-	if err := db.Where("id = ?", vehicleID).First(&vehicle).Error; err != nil {
+	if err := db.Unscoped().Where("id = ?", vehicleID).First(&vehicle).Error; err != nil {
 		return nil, http.StatusNotFound, fmt.Errorf("vehicle not found: %v", err)
 	}
 
@@ -909,7 +1103,12 @@ func GenerateActivityReport(db *gorm.DB, from, to time.Time, visitorType *models
 		return nil, http.StatusBadRequest, fmt.Errorf("failed to get all activities for summary: %v", err)
 	}
 
-	summary := GenerateActivitySummary(allActivities)
+	vehicleTypes, err := vehicleTypeCodes(db)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get vehicle types for summary: %v", err)
+	}
+
+	summary := GenerateActivitySummary(allActivities, vehicleTypes)
 
 	paginationResponse := models.PaginationResponse{
 		CurrentPage:     pagination.Page,
@@ -944,7 +1143,12 @@ func GetPendingVehicles(db *gorm.DB, userID string, pagination models.Pagination
 		return nil, http.StatusNotFound, fmt.Errorf("user with ID %s not found", userID)
 	}
 
-	query := db.Model(&models.PendingVehicleExit{}).Where("user_id = ? AND status = ?", userID, "pending")
+	// pending exits of vehicles the user owns or is authorized to drive
+	query := db.Model(&models.PendingVehicleExit{}).
+		Where("status = ?", "pending").
+		Where(`vehicle_id IN (SELECT id FROM vehicles WHERE user_id = ? AND deleted_at IS NULL
+			UNION SELECT vehicle_id FROM vehicle_drivers WHERE user_id = ? AND status = ?)`,
+			userID, userID, models.VehicleDriverActive)
 
 	// Count total pending vehicles
 	if err := query.Count(&count).Error; err != nil {
@@ -986,26 +1190,84 @@ func GetPendingVehicles(db *gorm.DB, userID string, pagination models.Pagination
 	}, http.StatusOK, nil
 }
 
-func UpdatePendingVehicle(db *gorm.DB, req models.PendingUpdateReq) (int, error) {
+func UpdatePendingVehicle(db *gorm.DB, actor models.AuditActor, req models.PendingUpdateReq) (int, error) {
 	var pendEntry models.PendingVehicleExit
 
-	// Ensure the user exists
 	exists := models.CheckExists(db, &pendEntry, "id = ?", req.ID)
 	if !exists {
 		return http.StatusNotFound, fmt.Errorf("pending entry with ID %s not found", req.ID)
 	}
 
-	pendEntry.Status = req.Status
+	return resolvePendingExit(db, actor, pendEntry, req.UserID, req.Status == "confirmed")
+}
 
-	err := db.Save(pendEntry).Error
+// resolvePendingExit applies a driver's answer to an exit confirmation. Every
+// authorized driver is asked, so only the first answer counts; later ones
+// are rejected.
+func resolvePendingExit(db *gorm.DB, actor models.AuditActor, pending models.PendingVehicleExit, userID string, confirmed bool) (int, error) {
+	if !isAuthorizedDriver(db, pending.VehicleID, userID) {
+		return http.StatusForbidden, errors.New("you are not authorized to drive this vehicle")
+	}
+	if pending.Status != "pending" {
+		return http.StatusConflict, errors.New("exit confirmation has already been answered")
+	}
 
-	if req.Status != "confirmed" {
-		notifySecurity(pendEntry.PlateNumber, pendEntry.Timestamp, pendEntry.ExitPointID)
+	before := pending
+	pending.Status = "denied"
+	if confirmed {
+		pending.Status = "confirmed"
 	}
+	pending.RespondedByID = &userID
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PendingVehicleExit{}).
+			Where("id = ? AND status = ?", pending.ID, "pending").
+			Updates(map[string]any{"status": pending.Status, "responded_by_id": userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errPendingExitAnswered
+		}
+
+		if confirmed {
+			activity := models.VehicleActivity{
+				PlateNumber: pending.PlateNumber,
+				VisitorType: models.VisitorTypeRegistered,
+				IsEntry:     false,
+				Timestamp:   pending.Timestamp,
+				ExitPointID: &pending.ExitPointID,
+				VehicleID:   &pending.VehicleID,
+			}
+			if err := tx.Create(&activity).Error; err != nil {
+				return fmt.Errorf("failed to log exit: %v", err)
+			}
+			if err := recordAudit(tx, actor, AuditVehicleActivityLogged, AuditTargetVehicleActivity, activity.ID, nil, activity); err != nil {
+				return err
+			}
+		}
 
+		return recordAudit(tx, actor, AuditPendingExitResolved, AuditTargetPendingExit, pending.ID, before, pending)
+	})
+	if errors.Is(err, errPendingExitAnswered) {
+		return http.StatusConflict, err
+	}
 	if err != nil {
-		return http.StatusInternalServerError, errors.New("Failed to update pending entry")
+		return http.StatusInternalServerError, fmt.Errorf("failed to update pending entry: %v", err)
+	}
+
+	if !confirmed {
+		go notifySecurity(pending.PlateNumber, pending.Timestamp, pending.ExitPointID)
+	}
+
+	var vehicle models.Vehicle
+	if err := db.First(&vehicle, "id = ?", pending.VehicleID).Error; err == nil {
+		if driverIDs, err := authorizedDriverIDs(db, vehicle); err == nil {
+			go notifyExitConfirmationResolved(pending, driverIDs)
+		}
 	}
 
 	return http.StatusOK, nil
 }
+
+var errPendingExitAnswered = errors.New("exit confirmation has already been answered")
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"survielx-backend/mailer"
+	"survielx-backend/models"
+	"survielx-backend/utility"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func vehicleTransferTTL() time.Duration {
+	return utility.GetEnvDuration("VEHICLE_TRANSFER_TTL", 7*24*time.Hour)
+}
+
+// vehicleTransfersRequireApproval reports whether security has to approve a
+// transfer after the recipient accepts it.
+func vehicleTransfersRequireApproval() bool {
+	return utility.GetEnvBool("VEHICLE_TRANSFER_REQUIRES_APPROVAL", false)
+}
+
+// InitiateVehicleTransfer offers the owner's vehicle to the user with the
+// given email and lets them know by email.
+func InitiateVehicleTransfer(db *gorm.DB, actor models.AuditActor, vehicleID string, input models.CreateVehicleTransferInput) (*models.VehicleTransfer, int, error) {
+	var vehicle models.Vehicle
+	if !models.CheckExists(db, &vehicle, "id = ?", vehicleID) {
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+	}
+	if vehicle.UserID != actor.ID {
+		return nil, http.StatusForbidden, errors.New("only the owner can transfer this vehicle")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	// unknown and suspended accounts get the same answer, so the endpoint
+	// cannot be used to discover who is registered
+	var recipient models.User
+	if !models.CheckExists(db, &recipient, "LOWER(email) = ?", email) || recipient.IsSuspended() {
+		return nil, http.StatusBadRequest, errTransferRecipientUnavailable
+	}
+	if recipient.ID == vehicle.UserID {
+		return nil, http.StatusBadRequest, errors.New("you already own this vehicle")
+	}
+
+	transfer := models.VehicleTransfer{
+		VehicleID:        vehicle.ID,
+		FromUserID:       vehicle.UserID,
+		ToUserID:         recipient.ID,
+		ToEmail:          recipient.Email,
+		Status:           models.TransferStatusPendingAcceptance,
+		RequiresApproval: vehicleTransfersRequireApproval(),
+		ExpiresAt:        time.Now().Add(vehicleTransferTTL()),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// only one transfer per vehicle may be open at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, "id = ?", vehicle.ID).Error; err != nil {
+			return err
+		}
+		if err := expireVehicleTransfers(tx, "vehicle_id = ?", vehicle.ID); err != nil {
+			return err
+		}
+		if models.CheckExists(tx, &models.VehicleTransfer{}, "vehicle_id = ? AND status IN ?", vehicle.ID, openTransferStatuses()) {
+			return errTransferAlreadyOpen
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleTransferInitiated, AuditTargetVehicleTransfer, transfer.ID, nil, transfer)
+	})
+	if errors.Is(err, errTransferAlreadyOpen) {
+		return nil, http.StatusConflict, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create transfer: %v", err)
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nThe owner of vehicle %s would like to transfer it to you.\n"+
+		"Sign in to SurvielX before %s to accept or decline the transfer.\n",
+		recipient.Name, vehicle.PlateNumber, transfer.ExpiresAt.Format(time.RFC1123))
+
+	err = mailer.Send(mailer.Message{
+		To:      []string{recipient.Email},
+		Subject: "A vehicle is being transferred to you",
+		Body:    body,
+	})
+	if err != nil {
+		// the recipient still sees the transfer in their list
+		log.Println("Failed to send vehicle transfer email:", err)
+	}
+
+	return &transfer, http.StatusCreated, nil
+}
+
+var (
+	errTransferAlreadyOpen          = errors.New("this vehicle already has a transfer in progress")
+	errTransferRecipientUnavailable = errors.New("the vehicle cannot be transferred to this email")
+)
+
+func openTransferStatuses() []string {
+	return []string{models.TransferStatusPendingAcceptance, models.TransferStatusPendingApproval}
+}
+
+// expireVehicleTransfers closes transfers still waiting for the recipient
+// after their deadline.
+func expireVehicleTransfers(tx *gorm.DB, query string, args ...any) error {
+	now := time.Now()
+	return tx.Model(&models.VehicleTransfer{}).
+		Where(query, args...).
+		Where("status = ? AND expires_at < ?", models.TransferStatusPendingAcceptance, now).
+		Updates(map[string]any{"status": models.TransferStatusCancelled, "closed_at": now}).Error
+}
+
+// GetUserVehicleTransfers lists the transfers a user has sent or received.
+func GetUserVehicleTransfers(db *gorm.DB, userID string, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	if err := expireVehicleTransfers(db, "from_user_id = ? OR to_user_id = ?", userID, userID); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to expire transfers: %v", err)
+	}
+
+	query := db.Model(&models.VehicleTransfer{}).Where("from_user_id = ? OR to_user_id = ?", userID, userID)
+	return paginateVehicleTransfers(query, pagination)
+}
+
+// GetVehicleTransfers lists transfers for security, optionally by status.
+func GetVehicleTransfers(db *gorm.DB, status string, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	if err := expireVehicleTransfers(db, "1 = 1"); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to expire transfers: %v", err)
+	}
+
+	query := db.Model(&models.VehicleTransfer{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return paginateVehicleTransfers(query, pagination)
+}
+
+func paginateVehicleTransfers(query *gorm.DB, pagination models.Pagination) (*models.PaginatedResponse, int, error) {
+	var (
+		transfers []models.VehicleTransfer
+		count     int64
+	)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to count transfers: %v", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.Limit
+	totalPages := int(math.Ceil(float64(count) / float64(pagination.Limit)))
+
+	if err := query.Preload("Vehicle", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at desc").
+		Offset(offset).
+		Limit(pagination.Limit).
+		Find(&transfers).Error; err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to fetch transfers: %v", err)
+	}
+
+	return &models.PaginatedResponse{
+		Data: transfers,
+		Pagination: models.PaginationResponse{
+			CurrentPage:     pagination.Page,
+			PageCount:       len(transfers),
+			TotalPagesCount: totalPages,
+		},
+	}, http.StatusOK, nil
+}
+
+// AcceptVehicleTransfer records the recipient's acceptance and completes the
+// transfer unless security still has to approve it.
+func AcceptVehicleTransfer(db *gorm.DB, actor models.AuditActor, transferID string) (*models.VehicleTransfer, int, error) {
+	return updateVehicleTransfer(db, actor, transferID, func(tx *gorm.DB, transfer *models.VehicleTransfer) (string, int, error) {
+		if transfer.ToUserID != actor.ID {
+			return "", http.StatusForbidden, errors.New("only the recipient can accept this transfer")
+		}
+		if transfer.Status != models.TransferStatusPendingAcceptance {
+			return "", http.StatusBadRequest, errors.New("transfer is not waiting for acceptance")
+		}
+		if time.Now().After(transfer.ExpiresAt) {
+			return "", http.StatusBadRequest, errors.New("transfer has expired")
+		}
+
+		now := time.Now()
+		transfer.AcceptedAt = &now
+		if transfer.RequiresApproval {
+			transfer.Status = models.TransferStatusPendingApproval
+			return AuditVehicleTransferAccepted, http.StatusOK, nil
+		}
+
+		if code, err := completeVehicleTransfer(tx, transfer); err != nil {
+			return "", code, err
+		}
+		return AuditVehicleTransferCompleted, http.StatusOK, nil
+	})
+}
+
+func DeclineVehicleTransfer(db *gorm.DB, actor models.AuditActor, transferID string) (*models.VehicleTransfer, int, error) {
+	return updateVehicleTransfer(db, actor, transferID, func(tx *gorm.DB, transfer *models.VehicleTransfer) (string, int, error) {
+		if transfer.ToUserID != actor.ID {
+			return "", http.StatusForbidden, errors.New("only the recipient can decline this transfer")
+		}
+		if transfer.Status != models.TransferStatusPendingAcceptance {
+			return "", http.StatusBadRequest, errors.New("transfer is not waiting for acceptance")
+		}
+
+		now := time.Now()
+		transfer.Status = models.TransferStatusDeclined
+		transfer.ClosedAt = &now
+		return AuditVehicleTransferDeclined, http.StatusOK, nil
+	})
+}
+
+func CancelVehicleTransfer(db *gorm.DB, actor models.AuditActor, transferID string) (*models.VehicleTransfer, int, error) {
+	return updateVehicleTransfer(db, actor, transferID, func(tx *gorm.DB, transfer *models.VehicleTransfer) (string, int, error) {
+		if transfer.FromUserID != actor.ID {
+			return "", http.StatusForbidden, errors.New("only the owner can cancel this transfer")
+		}
+		if !transfer.IsOpen() {
+			return "", http.StatusBadRequest, errors.New("transfer is no longer open")
+		}
+
+		now := time.Now()
+		transfer.Status = models.TransferStatusCancelled
+		transfer.ClosedAt = &now
+		return AuditVehicleTransferCancelled, http.StatusOK, nil
+	})
+}
+
+// ReviewVehicleTransfer lets security approve or reject a transfer the
+// recipient has accepted.
+func ReviewVehicleTransfer(db *gorm.DB, actor models.AuditActor, transferID string, input models.ReviewVehicleTransferInput) (*models.VehicleTransfer, int, error) {
+	return updateVehicleTransfer(db, actor, transferID, func(tx *gorm.DB, transfer *models.VehicleTransfer) (string, int, error) {
+		if transfer.Status != models.TransferStatusPendingApproval {
+			return "", http.StatusBadRequest, errors.New("transfer is not waiting for approval")
+		}
+
+		transfer.ReviewedByID = &actor.ID
+		transfer.ReviewReason = input.Reason
+		if !input.Approve {
+			now := time.Now()
+			transfer.Status = models.TransferStatusRejected
+			transfer.ClosedAt = &now
+			return AuditVehicleTransferRejected, http.StatusOK, nil
+		}
+
+		if code, err := completeVehicleTransfer(tx, transfer); err != nil {
+			return "", code, err
+		}
+		return AuditVehicleTransferCompleted, http.StatusOK, nil
+	})
+}
+
+// updateVehicleTransfer locks a transfer, applies a state change to it and
+// saves and audits the result in one transaction.
+func updateVehicleTransfer(db *gorm.DB, actor models.AuditActor, transferID string, apply func(tx *gorm.DB, transfer *models.VehicleTransfer) (string, int, error)) (*models.VehicleTransfer, int, error) {
+	var (
+		transfer models.VehicleTransfer
+		code     = http.StatusOK
+	)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, "id = ?", transferID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+				return errors.New("transfer not found")
+			}
+			code = http.StatusInternalServerError
+			return err
+		}
+
+		before := transfer
+		action, applyCode, err := apply(tx, &transfer)
+		if err != nil {
+			code = applyCode
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Save(&transfer).Error; err != nil {
+			code = http.StatusInternalServerError
+			return err
+		}
+		return recordAudit(tx, actor, action, AuditTargetVehicleTransfer, transfer.ID, before, transfer)
+	})
+	if err != nil {
+		return nil, code, err
+	}
+
+	return &transfer, http.StatusOK, nil
+}
+
+// completeVehicleTransfer moves the vehicle to the recipient and closes the
+// previous owner's ownership record. The vehicle keeps its ID, so its
+// activity history follows it.
+func completeVehicleTransfer(tx *gorm.DB, transfer *models.VehicleTransfer) (int, error) {
+	var vehicle models.Vehicle
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, "id = ?", transfer.VehicleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return http.StatusNotFound, errors.New("vehicle no longer exists")
+		}
+		return http.StatusInternalServerError, err
+	}
+	if vehicle.UserID != transfer.FromUserID {
+		return http.StatusConflict, errors.New("vehicle has changed owner since the transfer was started")
+	}
+
+	var recipient models.User
+	if !models.CheckExists(tx, &recipient, "id = ?", transfer.ToUserID) || recipient.IsSuspended() {
+		return http.StatusBadRequest, errors.New("recipient can no longer receive vehicles")
+	}
+
+	now := time.Now()
+	if err := changeVehicleOwner(tx, vehicle, transfer.ToUserID, &transfer.ID, now); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	transfer.Status = models.TransferStatusCompleted
+	transfer.CompletedAt = &now
+	transfer.ClosedAt = &now
+	return http.StatusOK, nil
+}
+
+// changeVehicleOwner hands a vehicle to a new owner, closing the previous
+// owner's ownership record. The previous owner's drivers lose access along
+// with them.
+func changeVehicleOwner(tx *gorm.DB, vehicle models.Vehicle, newOwnerID string, transferID *string, at time.Time) error {
+	if err := ensureVehicleOwnership(tx, vehicle); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.VehicleOwnership{}).
+		Where("vehicle_id = ? AND ended_at IS NULL", vehicle.ID).
+		Update("ended_at", at).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&vehicle).Update("user_id", newOwnerID).Error; err != nil {
+		return err
+	}
+	if err := replaceVehicleDrivers(tx, vehicle, newOwnerID); err != nil {
+		return err
+	}
+
+	return tx.Create(&models.VehicleOwnership{
+		VehicleID:   vehicle.ID,
+		UserID:      newOwnerID,
+		PlateNumber: vehicle.PlateNumber,
+		TransferID:  transferID,
+		StartedAt:   at,
+	}).Error
+}
+
+// ensureVehicleOwnership opens an ownership record for the current owner of
+// a vehicle registered before ownership was tracked.
+func ensureVehicleOwnership(tx *gorm.DB, vehicle models.Vehicle) error {
+	if models.CheckExists(tx, &models.VehicleOwnership{}, "vehicle_id = ?", vehicle.ID) {
+		return nil
+	}
+
+	return tx.Create(&models.VehicleOwnership{
+		VehicleID:   vehicle.ID,
+		UserID:      vehicle.UserID,
+		PlateNumber: vehicle.PlateNumber,
+		StartedAt:   vehicle.CreatedAt,
+	}).Error
+}
+
+// GetVehicleOwnershipHistory lists who has owned a vehicle, most recent first.
+// Pass an empty ownerID to skip the ownership check.
+func GetVehicleOwnershipHistory(db *gorm.DB, vehicleID, ownerID string) ([]models.VehicleOwnership, int, error) {
+	var vehicle models.Vehicle
+	if !models.CheckExists(db.Unscoped(), &vehicle, "id = ?", vehicleID) {
+		return nil, http.StatusNotFound, errors.New("vehicle does not exist")
+	}
+	if ownerID != "" && vehicle.UserID != ownerID {
+		return nil, http.StatusForbidden, errors.New("you do not own this vehicle")
+	}
+
+	if err := ensureVehicleOwnership(db, vehicle); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to record current owner: %v", err)
+	}
+
+	var history []models.VehicleOwnership
+	if err := db.Preload("User").
+		Where("vehicle_id = ?", vehicleID).
+		Order("started_at desc").
+		Find(&history).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch ownership history: %v", err)
+	}
+
+	return history, http.StatusOK, nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"survielx-backend/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetVehicleTypes lists the catalog. Inactive types are only included when
+// asked for, as on the admin screen.
+func GetVehicleTypes(db *gorm.DB, includeInactive bool) ([]models.VehicleType, int, error) {
+	var vehicleTypes []models.VehicleType
+
+	query := db.Order("name")
+	if !includeInactive {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&vehicleTypes).Error; err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to fetch vehicle types: %v", err)
+	}
+
+	return vehicleTypes, http.StatusOK, nil
+}
+
+func CreateVehicleType(db *gorm.DB, actor models.AuditActor, input models.CreateVehicleTypeInput) (*models.VehicleType, int, error) {
+	if models.CheckExists(db, &models.VehicleType{}, "code = ?", input.Code) {
+		return nil, http.StatusConflict, fmt.Errorf("vehicle type %s already exists", input.Code)
+	}
+
+	vehicleType := models.VehicleType{
+		Code:           input.Code,
+		Name:           input.Name,
+		ParkingSize:    input.ParkingSize,
+		RequiresEscort: input.RequiresEscort,
+		Active:         true,
+	}
+	if vehicleType.ParkingSize == "" {
+		vehicleType.ParkingSize = models.ParkingSizeStandard
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vehicleType).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleTypeCreated, AuditTargetVehicleType, vehicleType.Code, nil, vehicleType)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to create vehicle type: %v", err)
+	}
+	refreshVehicleTypeCache()
+
+	return &vehicleType, http.StatusCreated, nil
+}
+
+// UpdateVehicleType edits a type's attributes. Setting Active to false stops
+// new vehicles and activities from using it without touching old records.
+func UpdateVehicleType(db *gorm.DB, actor models.AuditActor, code string, input models.UpdateVehicleTypeInput) (*models.VehicleType, int, error) {
+	var vehicleType models.VehicleType
+	if !models.CheckExists(db, &vehicleType, "code = ?", code) {
+		return nil, http.StatusNotFound, errors.New("vehicle type not found")
+	}
+
+	updates := map[string]any{}
+	if input.Name != nil {
+		updates["name"] = *input.Name
+	}
+	if input.ParkingSize != nil {
+		updates["parking_size"] = *input.ParkingSize
+	}
+	if input.RequiresEscort != nil {
+		updates["requires_escort"] = *input.RequiresEscort
+	}
+	if input.Active != nil {
+		updates["active"] = *input.Active
+	}
+	if len(updates) == 0 {
+		return nil, http.StatusBadRequest, errors.New("no changes made to the vehicle type")
+	}
+
+	before := vehicleType
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&vehicleType).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&vehicleType, "code = ?", code).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, AuditVehicleTypeUpdated, AuditTargetVehicleType, vehicleType.Code, before, vehicleType)
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to update vehicle type: %v", err)
+	}
+	refreshVehicleTypeCache()
+
+	return &vehicleType, http.StatusOK, nil
+}
+
+// vehicleTypeCacheTTL bounds how long a change made through another instance
+// takes to reach this one's validator.
+const vehicleTypeCacheTTL = time.Minute
+
+// vehicleTypeCache holds the active type codes, so validating a request does
+// not query the catalog.
+var vehicleTypeCache struct {
+	sync.RWMutex
+	reloadMu sync.Mutex
+	db       *gorm.DB
+	codes    map[string]bool
+	loadedAt time.Time
+}
+
+// InitVehicleTypeCache loads the active vehicle types used to validate
+// requests.
+func InitVehicleTypeCache(db *gorm.DB) error {
+	vehicleTypeCache.Lock()
+	vehicleTypeCache.db = db
+	vehicleTypeCache.Unlock()
+	return reloadVehicleTypeCache()
+}
+
+func reloadVehicleTypeCache() error {
+	// a failed reload is retried after the TTL rather than on every request
+	vehicleTypeCache.Lock()
+	db := vehicleTypeCache.db
+	vehicleTypeCache.loadedAt = time.Now()
+	vehicleTypeCache.Unlock()
+	if db == nil {
+		return errors.New("vehicle type cache is not initialized")
+	}
+
+	var codes []string
+	if err := db.Model(&models.VehicleType{}).Where("active = ?", true).Pluck("code", &codes).Error; err != nil {
+		return fmt.Errorf("failed to load vehicle types: %v", err)
+	}
+
+	active := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		active[code] = true
+	}
+
+	vehicleTypeCache.Lock()
+	vehicleTypeCache.codes = active
+	vehicleTypeCache.Unlock()
+	return nil
+}
+
+// refreshVehicleTypeCache reloads the cache after a catalog change. The
+// change is already committed, so a failure only delays it until the next
+// reload.
+func refreshVehicleTypeCache() {
+	if err := reloadVehicleTypeCache(); err != nil {
+		log.Println(err)
+	}
+}
+
+// IsVehicleType reports whether code is an active type in the catalog.
+func IsVehicleType(code string) bool {
+	if code == "" {
+		return false
+	}
+
+	reloadVehicleTypeCacheIfStale()
+
+	vehicleTypeCache.RLock()
+	defer vehicleTypeCache.RUnlock()
+	return vehicleTypeCache.codes[code]
+}
+
+// reloadVehicleTypeCacheIfStale reloads the cache once it is older than
+// vehicleTypeCacheTTL. Concurrent callers wait for a single reload.
+func reloadVehicleTypeCacheIfStale() {
+	vehicleTypeCache.RLock()
+	fresh := time.Since(vehicleTypeCache.loadedAt) < vehicleTypeCacheTTL
+	vehicleTypeCache.RUnlock()
+	if fresh {
+		return
+	}
+
+	vehicleTypeCache.reloadMu.Lock()
+	defer vehicleTypeCache.reloadMu.Unlock()
+
+	vehicleTypeCache.RLock()
+	loadedAt := vehicleTypeCache.loadedAt
+	vehicleTypeCache.RUnlock()
+
+	if time.Since(loadedAt) >= vehicleTypeCacheTTL {
+		refreshVehicleTypeCache()
+	}
+}
+
+// vehicleTypeCodes lists every type in the catalog, including inactive ones
+// that old records may still use.
+func vehicleTypeCodes(db *gorm.DB) ([]string, error) {
+	var codes []string
+	err := db.Model(&models.VehicleType{}).Order("code").Pluck("code", &codes).Error
+	return codes, err
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsVehicleType(t *testing.T) {
+	vehicleTypeCache.Lock()
+	vehicleTypeCache.codes = map[string]bool{"car": true, "bus": true}
+	vehicleTypeCache.loadedAt = time.Now()
+	vehicleTypeCache.Unlock()
+
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"car", true},
+		{"bus", true},
+		{"tricycle", false},
+		{"Car", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := IsVehicleType(tt.code); got != tt.want {
+				t.Errorf("IsVehicleType(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsVehicleTypeKeepsStaleCodesWhenReloadFails(t *testing.T) {
+	vehicleTypeCache.Lock()
+	vehicleTypeCache.db = nil
+	vehicleTypeCache.codes = map[string]bool{"car": true}
+	vehicleTypeCache.loadedAt = time.Now().Add(-2 * vehicleTypeCacheTTL)
+	vehicleTypeCache.Unlock()
+
+	if !IsVehicleType("car") {
+		t.Error("cached type rejected after a failed reload")
+	}
+
+	vehicleTypeCache.RLock()
+	loadedAt := vehicleTypeCache.loadedAt
+	vehicleTypeCache.RUnlock()
+	if time.Since(loadedAt) >= vehicleTypeCacheTTL {
+		t.Error("failed reload will be retried on the next request")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"survielx-backend/connections"
 	"survielx-backend/database"
+	"survielx-backend/mailer"
 	"survielx-backend/models"
 	"survielx-backend/utility"
 	"time"
@@ -14,17 +15,13 @@ import (
 	"gorm.io/gorm"
 )
 
-func notifyUserForExitConfirmation(pendingID, userID, token string) {
+// notifyUserForExitConfirmation asks each of the vehicle's drivers whether
+// they are the one leaving. They share one response token.
+func notifyUserForExitConfirmation(pendingID string, userIDs []string, token string) {
 	var (
 		 pending models.PendingVehicleExit
 		 vehicle models.Vehicle
 	)
-	connInterface, ok := connections.Clients.Load(userID)
-	if !ok {
-		log.Println("User not connected:", userID)
-		return
-	}
-	conn := connInterface.(*websocket.Conn)
 
 	tx := database.DB.Where("id = ?", pendingID).First(&pending)
 	if tx.Error != nil{
@@ -32,7 +29,7 @@ func notifyUserForExitConfirmation(pendingID, userID, token string) {
 		return
 	}
 
-	tx = database.DB.Where("plate_number = ?", pending.PlateNumber).First(&vehicle)
+	tx = database.DB.Where("id = ?", pending.VehicleID).First(&vehicle)
 	if tx.Error != nil {
 		log.Default().Println("unable to fetch vehicle information", tx.Error.Error())
 		return
@@ -46,8 +43,17 @@ func notifyUserForExitConfirmation(pendingID, userID, token string) {
 		"plateNumber": pending.PlateNumber,
 		"vehicleName": vehicle.Model,
 	}
-	if err := conn.WriteJSON(msg); err != nil {
-		log.Println("WS write error:", err)
+	for _, userID := range userIDs {
+		clients := connections.GetClients(userID)
+		if len(clients) == 0 {
+			log.Println("User not connected:", userID)
+			continue
+		}
+		for _, client := range clients {
+			if err := client.WriteJSON(msg); err != nil {
+				log.Println("WS write error:", err)
+			}
+		}
 	}
 }
 
@@ -63,29 +69,34 @@ func HandleUserResponse(userID string, rawMessage []byte) {
 
 	var pending models.PendingVehicleExit
 	err := database.DB.Where("id = ?", resp.PendingID).First(&pending).Error
-	if err != nil || pending.UserID != userID || pending.ResponseToken != resp.Token || pending.Status != "pending" {
+	if err != nil || pending.ResponseToken != resp.Token {
 		log.Println("Invalid pending exit response")
 		return
 	}
 
-	if resp.Confirmed {
-		activity := models.VehicleActivity{
-			PlateNumber: pending.PlateNumber,
-			VisitorType: models.VisitorTypeRegistered,
-			IsEntry:     false,
-			Timestamp:   pending.Timestamp,
-			ExitPointID: &pending.ExitPointID,
-			VehicleID:   &pending.VehicleID,
+	if _, err := resolvePendingExit(database.DB, models.UserActor(userID), pending, userID, resp.Confirmed); err != nil {
+		log.Println("Pending exit response rejected:", err)
+	}
+}
+
+// notifyExitConfirmationResolved tells the other drivers that someone has
+// already answered, so their prompt can be dismissed.
+func notifyExitConfirmationResolved(pending models.PendingVehicleExit, userIDs []string) {
+	msg := map[string]any{
+		"type":       "exit_confirmation_resolved",
+		"pending_id": pending.ID,
+		"status":     pending.Status,
+	}
+	for _, userID := range userIDs {
+		if pending.RespondedByID != nil && userID == *pending.RespondedByID {
+			continue
 		}
-		if err := database.DB.Create(&activity).Error; err != nil {
-			log.Println("Failed to log exit:", err)
+		for _, client := range connections.GetClients(userID) {
+			if err := client.WriteJSON(msg); err != nil {
+				log.Println("WS write error:", err)
+			}
 		}
-		pending.Status = "confirmed"
-	} else {
-		pending.Status = "denied"
-		notifySecurity(pending.PlateNumber, pending.Timestamp, pending.ExitPointID)
 	}
-	database.DB.Save(&pending)
 }
 
 func handleExitTimeout(pendingID string, db *gorm.DB, exitPointID string) {
@@ -93,8 +104,21 @@ func handleExitTimeout(pendingID string, db *gorm.DB, exitPointID string) {
 	var pending models.PendingVehicleExit
 	err := db.Where("id = ?", pendingID).First(&pending).Error
 	if err == nil && pending.Status == "pending" {
+		before := pending
 		pending.Status = "timed_out"
-		db.Save(&pending)
+		// a driver may answer while this runs; only one of them resolves it
+		var resolved bool
+		err := db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&pending).Where("status = ?", "pending").Update("status", pending.Status)
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			resolved = true
+			return recordAudit(tx, models.SystemActor(), AuditPendingExitResolved, AuditTargetPendingExit, pending.ID, before, pending)
+		})
+		if err != nil || !resolved {
+			return
+		}
 		notifySecurity(pending.PlateNumber, pending.Timestamp, exitPointID)
 	}
 }
@@ -119,10 +143,8 @@ func notifySecurity(plateNumber string, timestamp time.Time, apID string) {
 	}
 
 	securityUserID := user.ID
-	connInterface, ok := connections.Clients.Load(securityUserID)
-	if ok {
-		conn := connInterface.(*websocket.Conn)
-
+	clients := connections.GetClients(securityUserID)
+	if len(clients) > 0 {
 		alert := map[string]interface{}{
 			"type": "security_alert",
 			"data": map[string]interface{}{
@@ -134,8 +156,15 @@ func notifySecurity(plateNumber string, timestamp time.Time, apID string) {
 			},
 		}
 
-		if err := conn.WriteJSON(alert); err != nil {
-			log.Printf("Failed to send security alert: %v", err)
+		delivered := false
+		for _, client := range clients {
+			if err := client.WriteJSON(alert); err != nil {
+				log.Printf("Failed to send security alert: %v", err)
+				continue
+			}
+			delivered = true
+		}
+		if !delivered {
 			sendEmailToSecurity(fmt.Sprintf("Alert: Suspicious exit for %s", plateNumber))
 		}
 	} else {
@@ -145,12 +174,38 @@ func notifySecurity(plateNumber string, timestamp time.Time, apID string) {
 }
 
 func sendEmailToSecurity(content string) {
-	log.Println("Sending email to security:", content)
+	var emails []string
+	if err := database.DB.Model(&models.User{}).Where("role = ?", "security").Pluck("email", &emails).Error; err != nil {
+		log.Println("Failed to find security emails:", err)
+		return
+	}
+
+	if len(emails) == 0 {
+		log.Println("No security users to email:", content)
+		return
+	}
+
+	err := mailer.Send(mailer.Message{
+		To:      emails,
+		Subject: "SurvielX security alert",
+		Body:    content,
+	})
+	if err != nil {
+		log.Println("Failed to email security:", err)
+	}
 }
 
 func SendNotification(userID string, message []byte) error {
-	if conn, ok := connections.GetClient(userID); ok {
-		return conn.WriteMessage(websocket.TextMessage, message)
+	clients := connections.GetClients(userID)
+	if len(clients) == 0 {
+		return fmt.Errorf("client not found")
+	}
+
+	var lastErr error
+	for _, client := range clients {
+		if err := client.WriteMessage(websocket.TextMessage, message); err != nil {
+			lastErr = err
+		}
 	}
-	return fmt.Errorf("client not found")
+	return lastErr
 }
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files. Implementations must be safe for concurrent
+// use. Keys are slash-separated paths such as "vehicles/<id>/<file>".
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	// Get opens a blob for reading; the caller closes it.
+	Get(key string) (io.ReadCloser, string, error)
+	Delete(key string) error
+	// SignedURL returns a URL that downloads the blob without further
+	// authentication until it expires.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// Default is the store used by the services. It writes to ./uploads until
+// Init configures the driver selected through STORAGE_DRIVER.
+var Default BlobStore = NewLocalStore("uploads", "", nil)
+
+// Init selects the storage driver from the environment:
+//
+//	STORAGE_DRIVER=local  writes under STORAGE_LOCAL_PATH (default ./uploads); links point at
+//	                      STORAGE_PUBLIC_URL and are signed with STORAGE_SIGNING_SECRET
+//	STORAGE_DRIVER=s3     uses S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID,
+//	                      S3_SECRET_ACCESS_KEY and S3_PATH_STYLE (for MinIO and similar)
+func Init() error {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		Default = store
+	case "", "local":
+		path := os.Getenv("STORAGE_LOCAL_PATH")
+		if path == "" {
+			path = "uploads"
+		}
+		secret := []byte(os.Getenv("STORAGE_SIGNING_SECRET"))
+		if len(secret) == 0 {
+			log.Println("STORAGE_SIGNING_SECRET is not set; download links will stop working on restart")
+		}
+		Default = NewLocalStore(path, os.Getenv("STORAGE_PUBLIC_URL"), secret)
+	default:
+		return errors.New("unknown STORAGE_DRIVER " + os.Getenv("STORAGE_DRIVER"))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package utility
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+)
+
+// encryptedPrefix marks values sealed by EncryptString and versions the
+// scheme, AES-256-GCM under DATA_ENCRYPTION_KEY.
+const encryptedPrefix = "enc:v1:"
+
+var ErrNotEncrypted = errors.New("value is not encrypted")
+
+// dataEncryptionKey reads DATA_ENCRYPTION_KEY, a base64 encoded 32-byte key.
+// It is kept out of the database so a dump or backup alone does not reveal
+// the secrets stored in it.
+func dataEncryptionKey() ([]byte, error) {
+	raw := os.Getenv("DATA_ENCRYPTION_KEY")
+	if raw == "" {
+		return nil, errors.New("DATA_ENCRYPTION_KEY is not set")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("DATA_ENCRYPTION_KEY must be 32 bytes, base64 encoded")
+	}
+	return key, nil
+}
+
+// CheckEncryptionKey reports whether DATA_ENCRYPTION_KEY is usable, so a
+// missing key stops the server at startup rather than on first use.
+func CheckEncryptionKey() error {
+	_, err := dataEncryptionKey()
+	return err
+}
+
+func dataCipher() (cipher.AEAD, error) {
+	key, err := dataEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptString seals a secret for storage.
+func EncryptString(plaintext string) (string, error) {
+	aead, err := dataCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString opens a value sealed by EncryptString.
+func DecryptString(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", ErrNotEncrypted
+	}
+
+	aead, err := dataCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt value")
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a stored value was sealed by EncryptString.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
@@ -0,0 +1,77 @@
+package utility
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestEncryptString(t *testing.T) {
+	t.Setenv("DATA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+
+	for _, plaintext := range []string{"", "secret", strings.Repeat("x", 4096)} {
+		sealed, err := EncryptString(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptString(%q) failed: %v", plaintext, err)
+		}
+		if !IsEncrypted(sealed) || (plaintext != "" && strings.Contains(sealed, plaintext)) {
+			t.Fatalf("EncryptString(%q) = %q, not sealed", plaintext, sealed)
+		}
+
+		opened, err := DecryptString(sealed)
+		if err != nil || opened != plaintext {
+			t.Fatalf("DecryptString = %q, %v; want %q", opened, err, plaintext)
+		}
+	}
+}
+
+func TestDecryptStringRejects(t *testing.T) {
+	t.Setenv("DATA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	sealed, err := EncryptString("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := []byte(sealed)
+	tampered[len(tampered)-2] ^= 1
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"plaintext", "secret"},
+		{"tampered", string(tampered)},
+		{"truncated", encryptedPrefix + "AAAA"},
+		{"not base64", encryptedPrefix + "!!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecryptString(tt.value); err == nil {
+				t.Fatalf("DecryptString(%q) succeeded", tt.value)
+			}
+		})
+	}
+
+	t.Setenv("DATA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32))))
+	if _, err := DecryptString(sealed); err == nil {
+		t.Fatal("DecryptString succeeded under another key")
+	}
+}
+
+func TestCheckEncryptionKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"", false},
+		{"not base64!", false},
+		{base64.StdEncoding.EncodeToString([]byte("short")), false},
+		{base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))), true},
+	}
+	for _, tt := range tests {
+		t.Setenv("DATA_ENCRYPTION_KEY", tt.key)
+		if err := CheckEncryptionKey(); (err == nil) != tt.valid {
+			t.Errorf("CheckEncryptionKey with %q: %v", tt.key, err)
+		}
+	}
+}
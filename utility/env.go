@@ -0,0 +1,52 @@
+package utility
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnvDuration reads a duration such as "15m" or "720h" from the environment,
+// falling back when the variable is unset or malformed.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// GetEnvBool reads a boolean such as "true" or "0" from the environment,
+// falling back when the variable is unset or malformed.
+func GetEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvInt reads an integer from the environment, falling back when the
+// variable is unset or malformed.
+func GetEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// GetEnvFloat reads a decimal number from the environment, falling back when
+// the variable is unset or malformed.
+func GetEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
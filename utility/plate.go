@@ -0,0 +1,62 @@
+package utility
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlateConfusions groups characters that ANPR cameras commonly read as one
+// another.
+var PlateConfusions = [][]rune{
+	{'O', '0', 'D', 'Q'},
+	{'I', '1', 'L', 'T'},
+	{'B', '8'},
+	{'S', '5'},
+	{'Z', '2'},
+	{'G', '6'},
+	{'A', '4'},
+}
+
+var plateSkeletonFrom, plateSkeletonTo = func() (string, string) {
+	var from, to strings.Builder
+	for _, group := range PlateConfusions {
+		for _, r := range group[1:] {
+			from.WriteRune(r)
+			to.WriteRune(group[0])
+		}
+	}
+	return from.String(), to.String()
+}()
+
+// NormalizePlateNumber returns the canonical form of a plate number used for
+// storage and lookups: upper case letters and digits only, so "LA 123-ABC"
+// and "la123abc" are the same plate.
+func NormalizePlateNumber(plateNumber string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return -1
+	}, plateNumber)
+}
+
+// PlateSkeleton replaces every character of a normalized plate with the first
+// of its confusion group, so plates that differ only by misreads share a
+// skeleton.
+func PlateSkeleton(normalized string) string {
+	return strings.Map(func(r rune) rune {
+		if i := strings.IndexRune(plateSkeletonFrom, r); i >= 0 {
+			return rune(plateSkeletonTo[i])
+		}
+		return r
+	}, normalized)
+}
+
+// PlateSkeletonSQL is the Postgres expression computing PlateSkeleton of a
+// column. Queries must use it verbatim to be served by the trigram index.
+func PlateSkeletonSQL(column string) string {
+	return fmt.Sprintf("translate(%s, '%s', '%s')", column, plateSkeletonFrom, plateSkeletonTo)
+}
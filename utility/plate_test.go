@@ -0,0 +1,47 @@
+package utility
+
+import "testing"
+
+func TestNormalizePlateNumber(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"LA 123-ABC", "LA123ABC"},
+		{"la123abc", "LA123ABC"},
+		{" ab.12 3 ", "AB123"},
+		{"ÄB-12", "B12"},
+		{"--", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizePlateNumber(tt.input); got != tt.want {
+			t.Errorf("NormalizePlateNumber(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPlateSkeleton(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"ABC123DE", "ABC123DE", true},
+		{"ABC123DE", "48C1230E", true},
+		{"LA123ABC", "1A123A8C", true},
+		{"SZG", "526", true},
+		{"ABC123DE", "ABC124DE", false},
+		{"ABC123DE", "XBC123DE", false},
+	}
+	for _, tt := range tests {
+		if same := PlateSkeleton(tt.a) == PlateSkeleton(tt.b); same != tt.same {
+			t.Errorf("PlateSkeleton(%q) == PlateSkeleton(%q) is %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
+
+func TestPlateSkeletonSQL(t *testing.T) {
+	want := "translate(normalized_plate, '0DQ1LT85264', 'OOOIIIBSZGA')"
+	if got := PlateSkeletonSQL("normalized_plate"); got != want {
+		t.Errorf("PlateSkeletonSQL = %q, want %q", got, want)
+	}
+}
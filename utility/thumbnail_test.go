@@ -0,0 +1,78 @@
+package utility
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withPNGSize rewrites the dimensions in a PNG header, leaving the pixel
+// data as it was.
+func withPNGSize(data []byte, width, height uint32) []byte {
+	out := bytes.Clone(data)
+	// signature (8), chunk length (4), "IHDR" (4), then width and height
+	binary.BigEndian.PutUint32(out[16:], width)
+	binary.BigEndian.PutUint32(out[20:], height)
+	binary.BigEndian.PutUint32(out[29:], crc32.ChecksumIEEE(out[12:29]))
+	return out
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		maxSize       int
+		width, height int
+		wantErr       bool
+	}{
+		{name: "landscape scaled down", data: testPNG(t, 400, 200), maxSize: 100, width: 100, height: 50},
+		{name: "portrait scaled down", data: testPNG(t, 200, 400), maxSize: 100, width: 50, height: 100},
+		{name: "small image kept", data: testPNG(t, 40, 30), maxSize: 100, width: 40, height: 30},
+		{name: "sliver keeps a pixel", data: testPNG(t, 1000, 2), maxSize: 100, width: 100, height: 1},
+		{name: "too many pixels", data: withPNGSize(testPNG(t, 4, 4), 5000, 5000), maxSize: 100, wantErr: true},
+		{name: "not an image", data: []byte("not an image"), maxSize: 100, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, err := MakeThumbnail(tt.data, tt.maxSize)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != tt.width || cfg.Height != tt.height {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.width, tt.height)
+			}
+		})
+	}
+}